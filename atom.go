@@ -2,10 +2,14 @@
 //
 // The wrapper types do not introduce any size overhead and have the same size
 // as the wrapped type.
+//
+// Goroutines may block until a value changes or satisfies a condition with
+// WaitChange and WaitUntil. Modifications only take a slow path to wake up
+// waiting goroutines while any goroutine is actually waiting.
 package atom
 
 import (
-	"errors"
+	"context"
 	"math"
 	"sync/atomic"
 	"time"
@@ -38,7 +42,11 @@ func (b *Bool) CompareAndSwap(old, new bool) (swapped bool) {
 	if new {
 		unew = 1
 	}
-	return atomic.CompareAndSwapUint32(&b.value, uold, unew)
+	swapped = atomic.CompareAndSwapUint32(&b.value, uold, unew)
+	if swapped {
+		notify(b)
+	}
+	return swapped
 }
 
 // Set sets the new value regardless of the previous value.
@@ -48,14 +56,18 @@ func (b *Bool) Set(value bool) {
 	} else {
 		atomic.StoreUint32(&b.value, 0)
 	}
+	notify(b)
 }
 
 // Swap atomically sets the new value and returns the previous value.
 func (b *Bool) Swap(new bool) (old bool) {
 	if new {
-		return atomic.SwapUint32(&b.value, 1) > 0
+		old = atomic.SwapUint32(&b.value, 1) > 0
+	} else {
+		old = atomic.SwapUint32(&b.value, 0) > 0
 	}
-	return atomic.SwapUint32(&b.value, 0) > 0
+	notify(b)
+	return old
 }
 
 // Value returns the current value.
@@ -63,6 +75,26 @@ func (b *Bool) Value() (value bool) {
 	return atomic.LoadUint32(&b.value) > 0
 }
 
+// WaitChange blocks until the value differs from old or the context is done.
+// It returns the new value, or the context's error in the latter case.
+func (b *Bool) WaitChange(ctx context.Context, old bool) (new bool, err error) {
+	return b.WaitUntil(ctx, func(value bool) bool {
+		return value != old
+	})
+}
+
+// WaitUntil blocks until cond returns true for the current value or the
+// context is done. It returns the value which satisfied cond, or the context's
+// error in the latter case.
+// cond is evaluated after every change and must not block.
+func (b *Bool) WaitUntil(ctx context.Context, cond func(value bool) bool) (value bool, err error) {
+	err = park(ctx, b, func() bool {
+		value = b.Value()
+		return cond(value)
+	})
+	return value, err
+}
+
 // Duration is a wrapper for atomically accessed time.Duration values.
 type Duration struct {
 	_     noCopy
@@ -72,29 +104,40 @@ type Duration struct {
 // Add atomically adds delta to the current value and returns the new value.
 // No arithmetic overflow checks are applied.
 func (d *Duration) Add(delta time.Duration) (new time.Duration) {
-	return time.Duration(atomic.AddInt64(&d.value, int64(delta)))
+	new = time.Duration(atomic.AddInt64(&d.value, int64(delta)))
+	notify(d)
+	return new
 }
 
 // CompareAndSwap atomically sets the new value only if the current value
 // matches the given old value and returns whether the new value was set.
 func (d *Duration) CompareAndSwap(old, new time.Duration) (swapped bool) {
-	return atomic.CompareAndSwapInt64(&d.value, int64(old), int64(new))
+	swapped = atomic.CompareAndSwapInt64(&d.value, int64(old), int64(new))
+	if swapped {
+		notify(d)
+	}
+	return swapped
 }
 
 // Set sets the new value regardless of the previous value.
 func (d *Duration) Set(value time.Duration) {
 	atomic.StoreInt64(&d.value, int64(value))
+	notify(d)
 }
 
 // Sub atomically subtracts delta to the current value and returns the new value.
 // No arithmetic underflow checks are applied.
 func (d *Duration) Sub(delta time.Duration) (new time.Duration) {
-	return time.Duration(atomic.AddInt64(&d.value, -int64(delta)))
+	new = time.Duration(atomic.AddInt64(&d.value, -int64(delta)))
+	notify(d)
+	return new
 }
 
 // Swap atomically sets the new value and returns the previous value.
 func (d *Duration) Swap(new time.Duration) (old time.Duration) {
-	return time.Duration(atomic.SwapInt64(&d.value, int64(new)))
+	old = time.Duration(atomic.SwapInt64(&d.value, int64(new)))
+	notify(d)
+	return old
 }
 
 // Value returns the current value.
@@ -102,33 +145,81 @@ func (d *Duration) Value() (value time.Duration) {
 	return time.Duration(atomic.LoadInt64(&d.value))
 }
 
-// errNil is a special error signaling a nil value.
-var errNil = errors.New("nil")
+// WaitChange blocks until the value differs from old or the context is done.
+// It returns the new value, or the context's error in the latter case.
+func (d *Duration) WaitChange(ctx context.Context, old time.Duration) (new time.Duration, err error) {
+	return d.WaitUntil(ctx, func(value time.Duration) bool {
+		return value != old
+	})
+}
+
+// WaitUntil blocks until cond returns true for the current value or the
+// context is done. It returns the value which satisfied cond, or the context's
+// error in the latter case.
+// cond is evaluated after every change and must not block.
+func (d *Duration) WaitUntil(ctx context.Context, cond func(value time.Duration) bool) (value time.Duration, err error) {
+	err = park(ctx, d, func() bool {
+		value = d.Value()
+		return cond(value)
+	})
+	return value, err
+}
 
 // Error is a wrapper for atomically accessed error values
 type Error struct {
 	_     noCopy
-	value atomic.Value
+	value atomic.Pointer[error]
 }
 
 // Set sets the new value regardless of the previous value.
 // The value may be nil.
 func (e *Error) Set(value error) {
-	// Setting atomic.Value to nil is not allowed.
-	// Use the special error errNil instead to signal a nil value.
-	if value == nil {
-		value = errNil
+	e.value.Store(&value)
+	notify(e)
+}
+
+// SetIfNil sets the new value only if the current value is nil and reports
+// whether the new value was set. Unlike Set, it thus keeps the first error,
+// e.g. of a number of concurrent workers.
+func (e *Error) SetIfNil(value error) (set bool) {
+	for {
+		old := e.value.Load()
+		if old != nil && *old != nil {
+			return false
+		}
+		if e.value.CompareAndSwap(old, &value) {
+			notify(e)
+			return true
+		}
 	}
-	e.value.Store(value)
 }
 
 // Value returns the current error value.
 func (e *Error) Value() (value error) {
-	v := e.value.Load()
-	if v == nil || v == errNil {
-		return nil
+	if p := e.value.Load(); p != nil {
+		return *p
 	}
-	return v.(error)
+	return nil
+}
+
+// WaitChange blocks until the value differs from old or the context is done.
+// It returns the new value, or the context's error in the latter case.
+func (e *Error) WaitChange(ctx context.Context, old error) (new error, err error) {
+	return e.WaitUntil(ctx, func(value error) bool {
+		return value != old
+	})
+}
+
+// WaitUntil blocks until cond returns true for the current value or the
+// context is done. It returns the value which satisfied cond, or the context's
+// error in the latter case.
+// cond is evaluated after every change and must not block.
+func (e *Error) WaitUntil(ctx context.Context, cond func(value error) bool) (value error, err error) {
+	err = park(ctx, e, func() bool {
+		value = e.Value()
+		return cond(value)
+	})
+	return value, err
 }
 
 // Float32 is a wrapper for atomically accessed float32 values.
@@ -152,12 +243,17 @@ func (f *Float32) Add(delta float32) (new float32) {
 // CompareAndSwap atomically sets the new value only if the current value
 // matches the given old value and returns whether the new value was set.
 func (f *Float32) CompareAndSwap(old, new float32) (swapped bool) {
-	return atomic.CompareAndSwapUint32(&f.value, math.Float32bits(old), math.Float32bits(new))
+	swapped = atomic.CompareAndSwapUint32(&f.value, math.Float32bits(old), math.Float32bits(new))
+	if swapped {
+		notify(f)
+	}
+	return swapped
 }
 
 // Set sets the new value regardless of the previous value.
 func (f *Float32) Set(value float32) {
 	atomic.StoreUint32(&f.value, math.Float32bits(value))
+	notify(f)
 }
 
 // Sub atomically subtracts delta to the current value and returns the new value.
@@ -167,7 +263,9 @@ func (f *Float32) Sub(delta float32) (new float32) {
 
 // Swap atomically sets the new value and returns the previous value.
 func (f *Float32) Swap(new float32) (old float32) {
-	return math.Float32frombits(atomic.SwapUint32(&f.value, math.Float32bits(new)))
+	old = math.Float32frombits(atomic.SwapUint32(&f.value, math.Float32bits(new)))
+	notify(f)
+	return old
 }
 
 // Value returns the current value.
@@ -175,6 +273,26 @@ func (f *Float32) Value() (value float32) {
 	return math.Float32frombits(atomic.LoadUint32(&f.value))
 }
 
+// WaitChange blocks until the value differs from old or the context is done.
+// It returns the new value, or the context's error in the latter case.
+func (f *Float32) WaitChange(ctx context.Context, old float32) (new float32, err error) {
+	return f.WaitUntil(ctx, func(value float32) bool {
+		return value != old
+	})
+}
+
+// WaitUntil blocks until cond returns true for the current value or the
+// context is done. It returns the value which satisfied cond, or the context's
+// error in the latter case.
+// cond is evaluated after every change and must not block.
+func (f *Float32) WaitUntil(ctx context.Context, cond func(value float32) bool) (value float32, err error) {
+	err = park(ctx, f, func() bool {
+		value = f.Value()
+		return cond(value)
+	})
+	return value, err
+}
+
 // Float64 is a wrapper for atomically accessed float64 values.
 type Float64 struct {
 	_     noCopy
@@ -196,12 +314,17 @@ func (f *Float64) Add(delta float64) (new float64) {
 // CompareAndSwap atomically sets the new value only if the current value
 // matches the given old value and returns whether the new value was set.
 func (f *Float64) CompareAndSwap(old, new float64) (swapped bool) {
-	return atomic.CompareAndSwapUint64(&f.value, math.Float64bits(old), math.Float64bits(new))
+	swapped = atomic.CompareAndSwapUint64(&f.value, math.Float64bits(old), math.Float64bits(new))
+	if swapped {
+		notify(f)
+	}
+	return swapped
 }
 
 // Set sets the new value regardless of the previous value.
 func (f *Float64) Set(value float64) {
 	atomic.StoreUint64(&f.value, math.Float64bits(value))
+	notify(f)
 }
 
 // Sub atomically subtracts delta to the current value and returns the new value.
@@ -211,7 +334,9 @@ func (f *Float64) Sub(delta float64) (new float64) {
 
 // Swap atomically sets the new value and returns the previous value.
 func (f *Float64) Swap(new float64) (old float64) {
-	return math.Float64frombits(atomic.SwapUint64(&f.value, math.Float64bits(new)))
+	old = math.Float64frombits(atomic.SwapUint64(&f.value, math.Float64bits(new)))
+	notify(f)
+	return old
 }
 
 // Value returns the current value.
@@ -219,6 +344,26 @@ func (f *Float64) Value() (value float64) {
 	return math.Float64frombits(atomic.LoadUint64(&f.value))
 }
 
+// WaitChange blocks until the value differs from old or the context is done.
+// It returns the new value, or the context's error in the latter case.
+func (f *Float64) WaitChange(ctx context.Context, old float64) (new float64, err error) {
+	return f.WaitUntil(ctx, func(value float64) bool {
+		return value != old
+	})
+}
+
+// WaitUntil blocks until cond returns true for the current value or the
+// context is done. It returns the value which satisfied cond, or the context's
+// error in the latter case.
+// cond is evaluated after every change and must not block.
+func (f *Float64) WaitUntil(ctx context.Context, cond func(value float64) bool) (value float64, err error) {
+	err = park(ctx, f, func() bool {
+		value = f.Value()
+		return cond(value)
+	})
+	return value, err
+}
+
 // Int is a wrapper for atomically accessed int values.
 type Int struct {
 	_     noCopy
@@ -227,18 +372,25 @@ type Int struct {
 
 // Add atomically adds delta to the current value and returns the new value.
 func (i *Int) Add(delta int) (new int) {
-	return int(atomic.AddUintptr(&i.value, uintptr(delta)))
+	new = int(atomic.AddUintptr(&i.value, uintptr(delta)))
+	notify(i)
+	return new
 }
 
 // CompareAndSwap atomically sets the new value only if the current value
 // matches the given old value and returns whether the new value was set.
 func (i *Int) CompareAndSwap(old, new int) (swapped bool) {
-	return atomic.CompareAndSwapUintptr(&i.value, uintptr(old), uintptr(new))
+	swapped = atomic.CompareAndSwapUintptr(&i.value, uintptr(old), uintptr(new))
+	if swapped {
+		notify(i)
+	}
+	return swapped
 }
 
 // Set sets the new value regardless of the previous value.
 func (i *Int) Set(value int) {
 	atomic.StoreUintptr(&i.value, uintptr(value))
+	notify(i)
 }
 
 // Sub atomically subtracts delta to the current value and returns the new value.
@@ -248,7 +400,9 @@ func (i *Int) Sub(delta int) (new int) {
 
 // Swap atomically sets the new value and returns the previous value.
 func (i *Int) Swap(new int) (old int) {
-	return int(atomic.SwapUintptr(&i.value, uintptr(new)))
+	old = int(atomic.SwapUintptr(&i.value, uintptr(new)))
+	notify(i)
+	return old
 }
 
 // Value returns the current value.
@@ -256,6 +410,26 @@ func (i *Int) Value() (value int) {
 	return int(atomic.LoadUintptr(&i.value))
 }
 
+// WaitChange blocks until the value differs from old or the context is done.
+// It returns the new value, or the context's error in the latter case.
+func (i *Int) WaitChange(ctx context.Context, old int) (new int, err error) {
+	return i.WaitUntil(ctx, func(value int) bool {
+		return value != old
+	})
+}
+
+// WaitUntil blocks until cond returns true for the current value or the
+// context is done. It returns the value which satisfied cond, or the context's
+// error in the latter case.
+// cond is evaluated after every change and must not block.
+func (i *Int) WaitUntil(ctx context.Context, cond func(value int) bool) (value int, err error) {
+	err = park(ctx, i, func() bool {
+		value = i.Value()
+		return cond(value)
+	})
+	return value, err
+}
+
 // Int32 is a wrapper for atomically accessed int32 values.
 type Int32 struct {
 	_     noCopy
@@ -264,18 +438,25 @@ type Int32 struct {
 
 // Add atomically adds delta to the current value and returns the new value.
 func (i *Int32) Add(delta int32) (new int32) {
-	return atomic.AddInt32(&i.value, delta)
+	new = atomic.AddInt32(&i.value, delta)
+	notify(i)
+	return new
 }
 
 // CompareAndSwap atomically sets the new value only if the current value
 // matches the given old value and returns whether the new value was set.
 func (i *Int32) CompareAndSwap(old, new int32) (swapped bool) {
-	return atomic.CompareAndSwapInt32(&i.value, old, new)
+	swapped = atomic.CompareAndSwapInt32(&i.value, old, new)
+	if swapped {
+		notify(i)
+	}
+	return swapped
 }
 
 // Set sets the new value regardless of the previous value.
 func (i *Int32) Set(value int32) {
 	atomic.StoreInt32(&i.value, value)
+	notify(i)
 }
 
 // Sub atomically subtracts delta to the current value and returns the new value.
@@ -285,7 +466,9 @@ func (i *Int32) Sub(delta int32) (new int32) {
 
 // Swap atomically sets the new value and returns the previous value.
 func (i *Int32) Swap(new int32) (old int32) {
-	return atomic.SwapInt32(&i.value, new)
+	old = atomic.SwapInt32(&i.value, new)
+	notify(i)
+	return old
 }
 
 // Value returns the current value.
@@ -293,6 +476,26 @@ func (i *Int32) Value() (value int32) {
 	return atomic.LoadInt32(&i.value)
 }
 
+// WaitChange blocks until the value differs from old or the context is done.
+// It returns the new value, or the context's error in the latter case.
+func (i *Int32) WaitChange(ctx context.Context, old int32) (new int32, err error) {
+	return i.WaitUntil(ctx, func(value int32) bool {
+		return value != old
+	})
+}
+
+// WaitUntil blocks until cond returns true for the current value or the
+// context is done. It returns the value which satisfied cond, or the context's
+// error in the latter case.
+// cond is evaluated after every change and must not block.
+func (i *Int32) WaitUntil(ctx context.Context, cond func(value int32) bool) (value int32, err error) {
+	err = park(ctx, i, func() bool {
+		value = i.Value()
+		return cond(value)
+	})
+	return value, err
+}
+
 // Int64 is a wrapper for atomically accessed int64 values.
 type Int64 struct {
 	_     noCopy
@@ -301,18 +504,25 @@ type Int64 struct {
 
 // Add atomically adds delta to the current value and returns the new value.
 func (i *Int64) Add(delta int64) (new int64) {
-	return atomic.AddInt64(&i.value, delta)
+	new = atomic.AddInt64(&i.value, delta)
+	notify(i)
+	return new
 }
 
 // CompareAndSwap atomically sets the new value only if the current value
 // matches the given old value and returns whether the new value was set.
 func (i *Int64) CompareAndSwap(old, new int64) (swapped bool) {
-	return atomic.CompareAndSwapInt64(&i.value, old, new)
+	swapped = atomic.CompareAndSwapInt64(&i.value, old, new)
+	if swapped {
+		notify(i)
+	}
+	return swapped
 }
 
 // Set sets the new value regardless of the previous value.
 func (i *Int64) Set(value int64) {
 	atomic.StoreInt64(&i.value, value)
+	notify(i)
 }
 
 // Sub atomically subtracts delta to the current value and returns the new value.
@@ -322,7 +532,9 @@ func (i *Int64) Sub(delta int64) (new int64) {
 
 // Swap atomically sets the new value and returns the previous value.
 func (i *Int64) Swap(new int64) (old int64) {
-	return atomic.SwapInt64(&i.value, new)
+	old = atomic.SwapInt64(&i.value, new)
+	notify(i)
+	return old
 }
 
 // Value returns the current value.
@@ -330,6 +542,26 @@ func (i *Int64) Value() (value int64) {
 	return atomic.LoadInt64(&i.value)
 }
 
+// WaitChange blocks until the value differs from old or the context is done.
+// It returns the new value, or the context's error in the latter case.
+func (i *Int64) WaitChange(ctx context.Context, old int64) (new int64, err error) {
+	return i.WaitUntil(ctx, func(value int64) bool {
+		return value != old
+	})
+}
+
+// WaitUntil blocks until cond returns true for the current value or the
+// context is done. It returns the value which satisfied cond, or the context's
+// error in the latter case.
+// cond is evaluated after every change and must not block.
+func (i *Int64) WaitUntil(ctx context.Context, cond func(value int64) bool) (value int64, err error) {
+	err = park(ctx, i, func() bool {
+		value = i.Value()
+		return cond(value)
+	})
+	return value, err
+}
+
 // String is a wrapper for atomically accessed string values.
 // Note: The string value is wrapped in an interface. Thus, this wrapper has
 // a memory overhead.
@@ -342,6 +574,7 @@ type String struct {
 // Note: Set requires an allocation as the value is wrapped in an interface.
 func (s *String) Set(value string) {
 	s.value.Store(value)
+	notify(s)
 }
 
 // Value returns the current error value.
@@ -353,6 +586,26 @@ func (s *String) Value() (value string) {
 	return v.(string)
 }
 
+// WaitChange blocks until the value differs from old or the context is done.
+// It returns the new value, or the context's error in the latter case.
+func (s *String) WaitChange(ctx context.Context, old string) (new string, err error) {
+	return s.WaitUntil(ctx, func(value string) bool {
+		return value != old
+	})
+}
+
+// WaitUntil blocks until cond returns true for the current value or the
+// context is done. It returns the value which satisfied cond, or the context's
+// error in the latter case.
+// cond is evaluated after every change and must not block.
+func (s *String) WaitUntil(ctx context.Context, cond func(value string) bool) (value string, err error) {
+	err = park(ctx, s, func() bool {
+		value = s.Value()
+		return cond(value)
+	})
+	return value, err
+}
+
 // Uint is a wrapper for atomically accessed uint values.
 type Uint struct {
 	_     noCopy
@@ -361,18 +614,25 @@ type Uint struct {
 
 // Add atomically adds delta to the current value and returns the new value.
 func (u *Uint) Add(delta uint) (new uint) {
-	return uint(atomic.AddUintptr(&u.value, uintptr(delta)))
+	new = uint(atomic.AddUintptr(&u.value, uintptr(delta)))
+	notify(u)
+	return new
 }
 
 // CompareAndSwap atomically sets the new value only if the current value
 // matches the given old value and returns whether the new value was set.
 func (u *Uint) CompareAndSwap(old, new uint) (swapped bool) {
-	return atomic.CompareAndSwapUintptr(&u.value, uintptr(old), uintptr(new))
+	swapped = atomic.CompareAndSwapUintptr(&u.value, uintptr(old), uintptr(new))
+	if swapped {
+		notify(u)
+	}
+	return swapped
 }
 
 // Set sets the new value regardless of the previous value.
 func (u *Uint) Set(value uint) {
 	atomic.StoreUintptr(&u.value, uintptr(value))
+	notify(u)
 }
 
 // Sub atomically subtracts delta to the current value and returns the new value.
@@ -382,7 +642,9 @@ func (u *Uint) Sub(delta uint) (new uint) {
 
 // Swap atomically sets the new value and returns the previous value.
 func (u *Uint) Swap(new uint) (old uint) {
-	return uint(atomic.SwapUintptr(&u.value, uintptr(new)))
+	old = uint(atomic.SwapUintptr(&u.value, uintptr(new)))
+	notify(u)
+	return old
 }
 
 // Value returns the current value.
@@ -390,6 +652,26 @@ func (u *Uint) Value() (value uint) {
 	return uint(atomic.LoadUintptr(&u.value))
 }
 
+// WaitChange blocks until the value differs from old or the context is done.
+// It returns the new value, or the context's error in the latter case.
+func (u *Uint) WaitChange(ctx context.Context, old uint) (new uint, err error) {
+	return u.WaitUntil(ctx, func(value uint) bool {
+		return value != old
+	})
+}
+
+// WaitUntil blocks until cond returns true for the current value or the
+// context is done. It returns the value which satisfied cond, or the context's
+// error in the latter case.
+// cond is evaluated after every change and must not block.
+func (u *Uint) WaitUntil(ctx context.Context, cond func(value uint) bool) (value uint, err error) {
+	err = park(ctx, u, func() bool {
+		value = u.Value()
+		return cond(value)
+	})
+	return value, err
+}
+
 // Uint32 is a wrapper for atomically accessed uint32 values.
 type Uint32 struct {
 	_     noCopy
@@ -398,18 +680,25 @@ type Uint32 struct {
 
 // Add atomically adds delta to the current value and returns the new value.
 func (u *Uint32) Add(delta uint32) (new uint32) {
-	return atomic.AddUint32(&u.value, delta)
+	new = atomic.AddUint32(&u.value, delta)
+	notify(u)
+	return new
 }
 
 // CompareAndSwap atomically sets the new value only if the current value
 // matches the given old value and returns whether the new value was set.
 func (u *Uint32) CompareAndSwap(old, new uint32) (swapped bool) {
-	return atomic.CompareAndSwapUint32(&u.value, old, new)
+	swapped = atomic.CompareAndSwapUint32(&u.value, old, new)
+	if swapped {
+		notify(u)
+	}
+	return swapped
 }
 
 // Set sets the new value regardless of the previous value.
 func (u *Uint32) Set(value uint32) {
 	atomic.StoreUint32(&u.value, value)
+	notify(u)
 }
 
 // Sub atomically subtracts delta to the current value and returns the new value.
@@ -419,7 +708,9 @@ func (u *Uint32) Sub(delta uint32) (new uint32) {
 
 // Swap atomically sets the new value and returns the previous value.
 func (u *Uint32) Swap(new uint32) (old uint32) {
-	return atomic.SwapUint32(&u.value, new)
+	old = atomic.SwapUint32(&u.value, new)
+	notify(u)
+	return old
 }
 
 // Value returns the current value.
@@ -427,6 +718,26 @@ func (u *Uint32) Value() (value uint32) {
 	return atomic.LoadUint32(&u.value)
 }
 
+// WaitChange blocks until the value differs from old or the context is done.
+// It returns the new value, or the context's error in the latter case.
+func (u *Uint32) WaitChange(ctx context.Context, old uint32) (new uint32, err error) {
+	return u.WaitUntil(ctx, func(value uint32) bool {
+		return value != old
+	})
+}
+
+// WaitUntil blocks until cond returns true for the current value or the
+// context is done. It returns the value which satisfied cond, or the context's
+// error in the latter case.
+// cond is evaluated after every change and must not block.
+func (u *Uint32) WaitUntil(ctx context.Context, cond func(value uint32) bool) (value uint32, err error) {
+	err = park(ctx, u, func() bool {
+		value = u.Value()
+		return cond(value)
+	})
+	return value, err
+}
+
 // Uint64 is a wrapper for atomically accessed uint64 values.
 type Uint64 struct {
 	_     noCopy
@@ -435,18 +746,25 @@ type Uint64 struct {
 
 // Add atomically adds delta to the current value and returns the new value.
 func (u *Uint64) Add(delta uint64) (new uint64) {
-	return atomic.AddUint64(&u.value, delta)
+	new = atomic.AddUint64(&u.value, delta)
+	notify(u)
+	return new
 }
 
 // CompareAndSwap atomically sets the new value only if the current value
 // matches the given old value and returns whether the new value was set.
 func (u *Uint64) CompareAndSwap(old, new uint64) (swapped bool) {
-	return atomic.CompareAndSwapUint64(&u.value, old, new)
+	swapped = atomic.CompareAndSwapUint64(&u.value, old, new)
+	if swapped {
+		notify(u)
+	}
+	return swapped
 }
 
 // Set sets the new value regardless of the previous value.
 func (u *Uint64) Set(value uint64) {
 	atomic.StoreUint64(&u.value, value)
+	notify(u)
 }
 
 // Sub atomically subtracts delta to the current value and returns the new value.
@@ -456,7 +774,9 @@ func (u *Uint64) Sub(delta uint64) (new uint64) {
 
 // Swap atomically sets the new value and returns the previous value.
 func (u *Uint64) Swap(new uint64) (old uint64) {
-	return atomic.SwapUint64(&u.value, new)
+	old = atomic.SwapUint64(&u.value, new)
+	notify(u)
+	return old
 }
 
 // Value returns the current value.
@@ -464,6 +784,33 @@ func (u *Uint64) Value() (value uint64) {
 	return atomic.LoadUint64(&u.value)
 }
 
+// WaitChange blocks until the value differs from old or the context is done.
+// It returns the new value, or the context's error in the latter case.
+func (u *Uint64) WaitChange(ctx context.Context, old uint64) (new uint64, err error) {
+	return u.WaitUntil(ctx, func(value uint64) bool {
+		return value != old
+	})
+}
+
+// WaitUntil blocks until cond returns true for the current value or the
+// context is done. It returns the value which satisfied cond, or the context's
+// error in the latter case.
+// cond is evaluated after every change and must not block.
+func (u *Uint64) WaitUntil(ctx context.Context, cond func(value uint64) bool) (value uint64, err error) {
+	err = park(ctx, u, func() bool {
+		value = u.Value()
+		return cond(value)
+	})
+	return value, err
+}
+
+// setQuiet sets the new value like Set, but does not wake up goroutines
+// waiting for a change. It is meant for words nobody waits on, such as the
+// indices of an SPSCRing, which must be updated without ever locking.
+func (u *Uint64) setQuiet(value uint64) {
+	atomic.StoreUint64(&u.value, value)
+}
+
 // Uintptr is a wrapper for atomically accessed uintptr values.
 type Uintptr struct {
 	_     noCopy
@@ -472,18 +819,25 @@ type Uintptr struct {
 
 // Add atomically adds delta to the current value and returns the new value.
 func (u *Uintptr) Add(delta uintptr) (new uintptr) {
-	return atomic.AddUintptr(&u.value, delta)
+	new = atomic.AddUintptr(&u.value, delta)
+	notify(u)
+	return new
 }
 
 // CompareAndSwap atomically sets the new value only if the current value
 // matches the given old value and returns whether the new value was set.
 func (u *Uintptr) CompareAndSwap(old, new uintptr) (swapped bool) {
-	return atomic.CompareAndSwapUintptr(&u.value, old, new)
+	swapped = atomic.CompareAndSwapUintptr(&u.value, old, new)
+	if swapped {
+		notify(u)
+	}
+	return swapped
 }
 
 // Set sets the new value regardless of the previous value.
 func (u *Uintptr) Set(value uintptr) {
 	atomic.StoreUintptr(&u.value, value)
+	notify(u)
 }
 
 // Sub atomically subtracts delta to the current value and returns the new value.
@@ -493,7 +847,9 @@ func (u *Uintptr) Sub(delta uintptr) (new uintptr) {
 
 // Swap atomically sets the new value and returns the previous value.
 func (u *Uintptr) Swap(new uintptr) (old uintptr) {
-	return atomic.SwapUintptr(&u.value, new)
+	old = atomic.SwapUintptr(&u.value, new)
+	notify(u)
+	return old
 }
 
 // Value returns the current value.
@@ -501,17 +857,60 @@ func (u *Uintptr) Value() (value uintptr) {
 	return atomic.LoadUintptr(&u.value)
 }
 
+// WaitChange blocks until the value differs from old or the context is done.
+// It returns the new value, or the context's error in the latter case.
+func (u *Uintptr) WaitChange(ctx context.Context, old uintptr) (new uintptr, err error) {
+	return u.WaitUntil(ctx, func(value uintptr) bool {
+		return value != old
+	})
+}
+
+// WaitUntil blocks until cond returns true for the current value or the
+// context is done. It returns the value which satisfied cond, or the context's
+// error in the latter case.
+// cond is evaluated after every change and must not block.
+func (u *Uintptr) WaitUntil(ctx context.Context, cond func(value uintptr) bool) (value uintptr, err error) {
+	err = park(ctx, u, func() bool {
+		value = u.Value()
+		return cond(value)
+	})
+	return value, err
+}
+
 // Value is a wrapper for atomically accessed consistently typed values.
 type Value struct {
 	_     noCopy
 	value atomic.Value
 }
 
+// CompareAndSwap atomically sets the new value only if the current value
+// matches the given old value and returns whether the new value was set.
+// The old value must be of a comparable type and the new value of the same
+// concrete type as all other values. CompareAndSwap of an inconsistent type
+// panics, as does CompareAndSwap(old, nil).
+func (v *Value) CompareAndSwap(old, new interface{}) (swapped bool) {
+	swapped = v.value.CompareAndSwap(old, new)
+	if swapped {
+		notify(v)
+	}
+	return swapped
+}
+
 // Set sets the new value regardless of the previous value.
 // All calls to Set for a given Value must use values of the same concrete type.
 // Set of an inconsistent type panics, as does Set(nil).
 func (v *Value) Set(value interface{}) {
 	v.value.Store(value)
+	notify(v)
+}
+
+// Swap atomically sets the new value and returns the previous value.
+// It returns nil if there has been no previous call to Set or Swap.
+// Swap of an inconsistent type panics, as does Swap(nil).
+func (v *Value) Swap(new interface{}) (old interface{}) {
+	old = v.value.Swap(new)
+	notify(v)
+	return old
 }
 
 // Value returns the current value.
@@ -519,3 +918,24 @@ func (v *Value) Set(value interface{}) {
 func (v *Value) Value() (value interface{}) {
 	return v.value.Load()
 }
+
+// WaitChange blocks until the value differs from old or the context is done.
+// It returns the new value, or the context's error in the latter case.
+// The values are compared with !=, which panics for values of incomparable types.
+func (v *Value) WaitChange(ctx context.Context, old interface{}) (new interface{}, err error) {
+	return v.WaitUntil(ctx, func(value interface{}) bool {
+		return value != old
+	})
+}
+
+// WaitUntil blocks until cond returns true for the current value or the
+// context is done. It returns the value which satisfied cond, or the context's
+// error in the latter case.
+// cond is evaluated after every change and must not block.
+func (v *Value) WaitUntil(ctx context.Context, cond func(value interface{}) bool) (value interface{}, err error) {
+	err = park(ctx, v, func() bool {
+		value = v.Value()
+		return cond(value)
+	})
+	return value, err
+}
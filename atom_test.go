@@ -1,7 +1,9 @@
 package atom
 
 import (
+	"context"
 	"errors"
+	"os"
 	"testing"
 	"time"
 )
@@ -124,6 +126,36 @@ func TestError(t *testing.T) {
 	} else if v != nil {
 		t.Fatal("Value did not match")
 	}
+
+	// errors of different types
+	e.Set(context.Canceled)
+	e.Set(&os.PathError{Op: "open", Path: "test", Err: a})
+	if _, ok := e.Value().(*os.PathError); !ok {
+		t.Fatal("Value did not match")
+	}
+}
+
+func TestErrorSetIfNil(t *testing.T) {
+	var e Error
+	a := errors.New("a")
+
+	if !e.SetIfNil(a) {
+		t.Fatal("SetIfNil did not set the value")
+	}
+	if e.SetIfNil(context.Canceled) {
+		t.Fatal("SetIfNil set the value although it was not nil")
+	}
+	if v := e.Value(); v != a {
+		t.Fatal("Value did not match")
+	}
+
+	e.Set(nil)
+	if !e.SetIfNil(context.Canceled) {
+		t.Fatal("SetIfNil did not set the value")
+	}
+	if v := e.Value(); v != context.Canceled {
+		t.Fatal("Value did not match")
+	}
 }
 
 func TestFloat32(t *testing.T) {
@@ -582,4 +614,25 @@ func TestValue(t *testing.T) {
 	if val := v.Value(); val != v2 {
 		t.Fatal("Value does not match")
 	}
+
+	if v.CompareAndSwap(v1, v1) {
+		t.Fatal("CompareAndSwap reported swap when the old value did not match")
+	}
+	if val := v.Value(); val != v2 {
+		t.Fatal("Value changed")
+	}
+
+	if !v.CompareAndSwap(v2, v1) {
+		t.Fatal("CompareAndSwap did not report a swap")
+	}
+	if val := v.Value(); val != v1 {
+		t.Fatal("Value unchanged")
+	}
+
+	if old := v.Swap(v2); old != v1 {
+		t.Fatal("Old value does not match")
+	}
+	if val := v.Value(); val != v2 {
+		t.Fatal("Value unchanged")
+	}
 }
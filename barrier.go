@@ -0,0 +1,144 @@
+package atom
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrBrokenBarrier is returned by Barrier.Await if the barrier is broken.
+var ErrBrokenBarrier = errors.New("broken barrier")
+
+// The barrier state is packed into a single Uint64:
+// the generation in the upper 32 bits, followed by the broken and the
+// reset-pending flags and the number of waiting parties in the lowest 30 bits.
+const (
+	barrierWaitingMask  = 1<<30 - 1
+	barrierBroken       = 1 << 30
+	barrierResetPending = 1 << 31
+	barrierGenShift     = 32
+)
+
+// Barrier is a reusable cyclic barrier for a fixed number of parties.
+// The parties block in Await until all of them arrived, then the barrier
+// trips, releases all of them and starts a new generation.
+//
+// If a waiting party gives up because its context is done, the barrier
+// breaks: all waiting and all further parties fail with ErrBrokenBarrier
+// until the barrier is Reset.
+//
+// Unlike a Latch, a Barrier must be created with NewBarrier.
+type Barrier struct {
+	_       noCopy
+	parties uint64
+	state   Uint64
+}
+
+// NewBarrier returns a new Barrier for the given number of parties.
+// It panics if parties is not positive or too large.
+func NewBarrier(parties int) *Barrier {
+	if parties < 1 || parties > barrierWaitingMask {
+		panic("atom: invalid number of barrier parties")
+	}
+	return &Barrier{parties: uint64(parties)}
+}
+
+// Await blocks until all parties arrived at the barrier or the context is
+// done. It returns the arrival index of the party, where parties-1 denotes
+// the last party, which tripped the barrier.
+// If the context is done first, the barrier breaks and the context's error is
+// returned. If the barrier is or becomes broken, ErrBrokenBarrier is returned.
+// It panics if the barrier was not created with NewBarrier.
+func (b *Barrier) Await(ctx context.Context) (index int, err error) {
+	if b.parties == 0 {
+		panic("atom: Await of Barrier not created with NewBarrier")
+	}
+
+	var gen uint64
+	for {
+		s := b.state.Value()
+		if s&barrierBroken != 0 {
+			return 0, ErrBrokenBarrier
+		}
+		waiting := s & barrierWaitingMask
+		gen = s >> barrierGenShift
+		if waiting+1 == b.parties {
+			// trip the barrier and start the next generation
+			if b.state.CompareAndSwap(s, barrierNextGeneration(gen)) {
+				return int(waiting), nil
+			}
+			continue
+		}
+		if b.state.CompareAndSwap(s, s+1) {
+			index = int(waiting)
+			break
+		}
+	}
+
+	// A broken generation can not trip before all its waiting parties left,
+	// thus a new generation means that the barrier tripped.
+	s, err := b.state.WaitUntil(ctx, func(s uint64) bool {
+		return s>>barrierGenShift != gen || s&barrierBroken != 0
+	})
+	if err == nil && s>>barrierGenShift != gen {
+		return index, nil
+	}
+
+	for {
+		s = b.state.Value()
+		if s>>barrierGenShift != gen {
+			// tripped after all
+			return index, nil
+		}
+		left := (s - 1) | barrierBroken
+		if left&barrierWaitingMask == 0 && left&barrierResetPending != 0 {
+			left = barrierNextGeneration(gen)
+		}
+		if b.state.CompareAndSwap(s, left) {
+			if err != nil {
+				return index, err
+			}
+			return index, ErrBrokenBarrier
+		}
+	}
+}
+
+// Broken reports whether the barrier is broken.
+func (b *Barrier) Broken() (broken bool) {
+	return b.state.Value()&barrierBroken != 0
+}
+
+// Generation returns the current generation, which is incremented every time
+// the barrier trips or is reset.
+func (b *Barrier) Generation() (generation uint32) {
+	return uint32(b.state.Value() >> barrierGenShift)
+}
+
+// Parties returns the number of parties required to trip the barrier.
+func (b *Barrier) Parties() (parties int) {
+	return int(b.parties)
+}
+
+// Reset resets the barrier to its initial state.
+// Parties currently waiting at the barrier fail with ErrBrokenBarrier. In this
+// case the reset completes as soon as all of them left.
+func (b *Barrier) Reset() {
+	for {
+		s := b.state.Value()
+		next := barrierNextGeneration(s >> barrierGenShift)
+		if s&barrierWaitingMask != 0 {
+			next = s | barrierBroken | barrierResetPending
+		}
+		if b.state.CompareAndSwap(s, next) {
+			return
+		}
+	}
+}
+
+// Waiting returns the number of parties currently waiting at the barrier.
+func (b *Barrier) Waiting() (waiting int) {
+	return int(b.state.Value() & barrierWaitingMask)
+}
+
+func barrierNextGeneration(gen uint64) uint64 {
+	return (gen + 1) << barrierGenShift
+}
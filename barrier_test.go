@@ -0,0 +1,137 @@
+package atom
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBarrier(t *testing.T) {
+	const parties = 4
+	b := NewBarrier(parties)
+	if p := b.Parties(); p != parties {
+		t.Fatal("Expected 4 parties, got", p)
+	}
+
+	for gen := 0; gen < 3; gen++ {
+		if g := b.Generation(); g != uint32(gen) {
+			t.Fatalf("Expected generation %d, got %d", gen, g)
+		}
+
+		var wg sync.WaitGroup
+		var indices [parties]Uint32
+		for i := 0; i < parties; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				index, err := b.Await(context.Background())
+				if err != nil {
+					t.Error("Unexpected error:", err)
+					return
+				}
+				indices[index].Add(1)
+			}()
+		}
+		wg.Wait()
+
+		for i := range indices {
+			if n := indices[i].Value(); n != 1 {
+				t.Fatalf("Expected arrival index %d once, got %d", i, n)
+			}
+		}
+		if w := b.Waiting(); w != 0 {
+			t.Fatal("Expected no waiting parties, got", w)
+		}
+	}
+}
+
+func TestBarrierBroken(t *testing.T) {
+	b := NewBarrier(3)
+
+	done := make(chan error)
+	go func() {
+		_, err := b.Await(context.Background())
+		done <- err
+	}()
+	for b.Waiting() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// a party giving up breaks the barrier
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	if _, err := b.Await(ctx); err != context.DeadlineExceeded {
+		t.Fatal("Expected context.DeadlineExceeded, got", err)
+	}
+	if err := <-done; err != ErrBrokenBarrier {
+		t.Fatal("Expected ErrBrokenBarrier, got", err)
+	}
+	if !b.Broken() {
+		t.Fatal("Barrier not broken")
+	}
+	if _, err := b.Await(context.Background()); err != ErrBrokenBarrier {
+		t.Fatal("Expected ErrBrokenBarrier, got", err)
+	}
+
+	gen := b.Generation()
+	b.Reset()
+	if b.Broken() {
+		t.Fatal("Barrier still broken after reset")
+	}
+	if g := b.Generation(); g != gen+1 {
+		t.Fatal("Expected a new generation after reset, got", g)
+	}
+}
+
+func TestBarrierResetWaiting(t *testing.T) {
+	b := NewBarrier(3)
+
+	done := make(chan error)
+	for i := 0; i < 2; i++ {
+		go func() {
+			_, err := b.Await(context.Background())
+			done <- err
+		}()
+	}
+	for b.Waiting() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+
+	b.Reset()
+	for i := 0; i < 2; i++ {
+		if err := <-done; err != ErrBrokenBarrier {
+			t.Fatal("Expected ErrBrokenBarrier, got", err)
+		}
+	}
+
+	// the reset completes when the last waiting party left
+	if b.Broken() {
+		t.Fatal("Barrier still broken after reset")
+	}
+	if w := b.Waiting(); w != 0 {
+		t.Fatal("Expected no waiting parties, got", w)
+	}
+	if g := b.Generation(); g != 1 {
+		t.Fatal("Expected generation 1, got", g)
+	}
+}
+
+func TestNewBarrierPanic(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("Expected panic for zero parties")
+		}
+	}()
+	NewBarrier(0)
+}
+
+func TestBarrierZeroValuePanic(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("Expected panic for zero value Barrier")
+		}
+	}()
+	var b Barrier
+	b.Await(context.Background())
+}
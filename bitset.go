@@ -0,0 +1,134 @@
+package atom
+
+import (
+	"math/bits"
+)
+
+// Bitset is a fixed-size set of bits with lock-free atomic access to the
+// individual bits, e.g. to track which of a number of workers or shards are
+// active. It uses a single bit per flag, 64 of which share a Uint64 word.
+//
+// Operations on a single bit are atomic. Operations on the whole set, such as
+// Count, FirstClear and Range, observe each word atomically but not the set as
+// a whole.
+type Bitset struct {
+	_     noCopy
+	n     int
+	words []Uint64
+}
+
+// NewBitset returns a new Bitset of n bits, which are all clear.
+func NewBitset(n int) *Bitset {
+	if n < 0 {
+		panic("atom: negative Bitset size")
+	}
+	return &Bitset{
+		n:     n,
+		words: make([]Uint64, (n+63)/64),
+	}
+}
+
+// Clear clears the bit i.
+// It panics if i is out of range.
+func (b *Bitset) Clear(i int) {
+	b.TestAndClear(i)
+}
+
+// Count returns the number of set bits.
+func (b *Bitset) Count() (n int) {
+	for i := range b.words {
+		n += bits.OnesCount64(b.words[i].Value())
+	}
+	return n
+}
+
+// FirstClear returns the index of the first clear bit or -1 if all bits are
+// set. It can be used together with TestAndSet to allocate slots:
+//
+//	i := b.FirstClear()
+//	for i >= 0 && b.TestAndSet(i) {
+//		i = b.FirstClear()
+//	}
+//	// i is the allocated slot, or -1 if all slots are in use
+func (b *Bitset) FirstClear() (i int) {
+	for w := range b.words {
+		if v := ^b.words[w].Value(); v != 0 {
+			if i = w*64 + bits.TrailingZeros64(v); i < b.n {
+				return i
+			}
+			return -1
+		}
+	}
+	return -1
+}
+
+// Len returns the number of bits in the set.
+func (b *Bitset) Len() (n int) {
+	return b.n
+}
+
+// Range calls f sequentially for the index of each set bit in ascending order.
+// If f returns false, Range stops the iteration.
+func (b *Bitset) Range(f func(i int) bool) {
+	for w := range b.words {
+		v := b.words[w].Value()
+		for v != 0 {
+			i := bits.TrailingZeros64(v)
+			if !f(w*64 + i) {
+				return
+			}
+			v &^= 1 << uint(i)
+		}
+	}
+}
+
+// Set sets the bit i.
+// It panics if i is out of range.
+func (b *Bitset) Set(i int) {
+	b.TestAndSet(i)
+}
+
+// Test reports whether the bit i is set.
+// It panics if i is out of range.
+func (b *Bitset) Test(i int) (set bool) {
+	word, mask := b.locate(i)
+	return word.Value()&mask != 0
+}
+
+// TestAndClear atomically clears the bit i and reports whether it was set.
+// It panics if i is out of range.
+func (b *Bitset) TestAndClear(i int) (wasSet bool) {
+	word, mask := b.locate(i)
+	for {
+		old := word.Value()
+		if old&mask == 0 {
+			return false
+		}
+		if word.CompareAndSwap(old, old&^mask) {
+			return true
+		}
+	}
+}
+
+// TestAndSet atomically sets the bit i and reports whether it was already set.
+// It panics if i is out of range.
+func (b *Bitset) TestAndSet(i int) (wasSet bool) {
+	word, mask := b.locate(i)
+	for {
+		old := word.Value()
+		if old&mask != 0 {
+			return true
+		}
+		if word.CompareAndSwap(old, old|mask) {
+			return false
+		}
+	}
+}
+
+// locate returns the word containing the bit i and the mask of the bit.
+func (b *Bitset) locate(i int) (word *Uint64, mask uint64) {
+	if i < 0 || i >= b.n {
+		panic("atom: Bitset index out of range")
+	}
+	return &b.words[i/64], 1 << uint(i%64)
+}
@@ -0,0 +1,142 @@
+package atom
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestBitset(t *testing.T) {
+	b := NewBitset(130)
+	if n := b.Len(); n != 130 {
+		t.Fatal("Expected length 130, got", n)
+	}
+	if n := b.Count(); n != 0 {
+		t.Fatal("Expected initial count 0, got", n)
+	}
+	if i := b.FirstClear(); i != 0 {
+		t.Fatal("Expected first clear bit 0, got", i)
+	}
+
+	for _, i := range []int{0, 63, 64, 129} {
+		if b.Test(i) {
+			t.Fatal("Bit set initially:", i)
+		}
+		b.Set(i)
+		if !b.Test(i) {
+			t.Fatal("Bit not set:", i)
+		}
+	}
+	if n := b.Count(); n != 4 {
+		t.Fatal("Expected count 4, got", n)
+	}
+	if i := b.FirstClear(); i != 1 {
+		t.Fatal("Expected first clear bit 1, got", i)
+	}
+
+	var set []int
+	b.Range(func(i int) bool {
+		set = append(set, i)
+		return true
+	})
+	if len(set) != 4 || set[0] != 0 || set[1] != 63 || set[2] != 64 || set[3] != 129 {
+		t.Fatal("Unexpected set bits:", set)
+	}
+	var calls int
+	b.Range(func(i int) bool {
+		calls++
+		return false
+	})
+	if calls != 1 {
+		t.Fatal("Range did not stop")
+	}
+
+	if !b.TestAndSet(63) {
+		t.Fatal("TestAndSet did not report set bit")
+	}
+	if b.TestAndSet(62) {
+		t.Fatal("TestAndSet reported clear bit as set")
+	}
+	if !b.TestAndClear(62) {
+		t.Fatal("TestAndClear did not report set bit")
+	}
+	if b.TestAndClear(62) {
+		t.Fatal("TestAndClear reported clear bit as set")
+	}
+	b.Clear(63)
+	if b.Test(63) {
+		t.Fatal("Bit still set")
+	}
+}
+
+func TestBitsetFull(t *testing.T) {
+	b := NewBitset(66)
+	for i := 0; i < 66; i++ {
+		b.Set(i)
+	}
+	if i := b.FirstClear(); i != -1 {
+		t.Fatal("Expected no clear bit, got", i)
+	}
+	if n := b.Count(); n != 66 {
+		t.Fatal("Expected count 66, got", n)
+	}
+
+	b = NewBitset(0)
+	if i := b.FirstClear(); i != -1 {
+		t.Fatal("Expected no clear bit, got", i)
+	}
+}
+
+func TestBitsetPanic(t *testing.T) {
+	b := NewBitset(10)
+	for _, i := range []int{-1, 10} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatal("Expected panic for index", i)
+				}
+			}()
+			b.Test(i)
+		}()
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("Expected panic for negative size")
+		}
+	}()
+	NewBitset(-1)
+}
+
+func TestBitsetAllocate(t *testing.T) {
+	const slots = 100
+	b := NewBitset(slots)
+
+	// allocate all slots concurrently, each exactly once
+	var allocated [slots]Uint32
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				i := b.FirstClear()
+				if i < 0 {
+					return
+				}
+				if !b.TestAndSet(i) {
+					allocated[i].Add(1)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i := range allocated {
+		if n := allocated[i].Value(); n != 1 {
+			t.Fatalf("Expected slot %d to be allocated once, got %d", i, n)
+		}
+	}
+	if n := b.Count(); n != slots {
+		t.Fatal("Expected all slots to be set, got", n)
+	}
+}
@@ -0,0 +1,282 @@
+package atom
+
+import (
+	"time"
+)
+
+// CircuitState is the state of a CircuitBreaker.
+type CircuitState int32
+
+// States of a CircuitBreaker.
+const (
+	// CircuitClosed lets all calls pass and counts consecutive failures.
+	CircuitClosed CircuitState = iota
+
+	// CircuitOpen rejects all calls until the cool-down has elapsed.
+	CircuitOpen
+
+	// CircuitHalfOpen lets a limited number of probe calls pass to test
+	// whether the protected resource has recovered.
+	CircuitHalfOpen
+)
+
+// String returns the name of the state.
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// circuitOpening is the internal state of a circuit which is being opened
+// and whose opening time is not yet recorded. It is reported as open.
+const circuitOpening CircuitState = 3
+
+// CircuitBreaker is a lock-free circuit breaker.
+// The state and all counters are kept in atomic words, thus all methods are
+// safe for concurrent use without further synchronization.
+//
+// Every state transition starts a new generation of the circuit. Allow returns
+// the generation in which a call was allowed and Success and Failure ignore
+// outcomes of calls of an earlier generation, e.g. of a call which was allowed
+// while the circuit was still closed but finished only after it half-opened.
+//
+// The zero value is a closed circuit breaker which opens after the first
+// failure, half-opens immediately and closes after one successful probe.
+// The thresholds and the cool-down may be adjusted at any time.
+// OnStateChange and Now must be set before the first use.
+type CircuitBreaker struct {
+	_ noCopy
+
+	// FailureThreshold is the number of consecutive failures after which
+	// a closed circuit opens. A value of 0 is treated as 1,
+	// values above 1<<16-1 as 1<<16-1.
+	FailureThreshold Uint32
+
+	// SuccessThreshold is the number of successful probes after which
+	// a half-open circuit closes again. A value of 0 is treated as 1,
+	// values above 1<<16-1 as 1<<16-1.
+	SuccessThreshold Uint32
+
+	// MaxProbes is the maximum number of concurrent probe calls allowed
+	// while the circuit is half-open. A value of 0 is treated as 1,
+	// values above 1<<16-1 as 1<<16-1.
+	MaxProbes Uint32
+
+	// CoolDown is the time an open circuit waits before it half-opens.
+	CoolDown Duration
+
+	// OnStateChange is called after every state transition by the goroutine
+	// which performed it. It is optional.
+	OnStateChange func(from, to CircuitState)
+
+	// Now returns the current time. If nil, time.Now is used.
+	Now func() time.Time
+
+	// The state, the generation and the counters are packed into a single
+	// word, so that a transition resets the counters and starts a new
+	// generation atomically together with the state.
+	state    Uint64
+	openedAt Int64
+}
+
+// Layout of the packed state word: the lowest bits hold the CircuitState,
+// followed by the number of consecutive failures of a closed circuit or of
+// running probes of a half-open circuit, the number of successful probes and
+// the generation.
+const (
+	circuitStateBits      = 2
+	circuitCountBits      = 16
+	circuitCountMax       = 1<<circuitCountBits - 1
+	circuitGenerationMask = 1<<(64-circuitStateBits-2*circuitCountBits) - 1
+)
+
+func packCircuit(state CircuitState, generation, count, successes uint32) uint64 {
+	return uint64(generation&circuitGenerationMask)<<(circuitStateBits+2*circuitCountBits) |
+		uint64(successes)<<(circuitStateBits+circuitCountBits) |
+		uint64(count)<<circuitStateBits |
+		uint64(state)
+}
+
+func unpackCircuit(word uint64) (state CircuitState, generation, count, successes uint32) {
+	state = CircuitState(word & (1<<circuitStateBits - 1))
+	count = uint32(word>>circuitStateBits) & circuitCountMax
+	successes = uint32(word>>(circuitStateBits+circuitCountBits)) & circuitCountMax
+	generation = uint32(word >> (circuitStateBits + 2*circuitCountBits))
+	return state, generation, count, successes
+}
+
+// Allow reports whether a call may pass the circuit breaker and returns the
+// generation in which it was allowed.
+// Each allowed call must report its outcome with either Success or Failure,
+// passing the returned generation.
+func (cb *CircuitBreaker) Allow() (generation uint32, allowed bool) {
+	for {
+		word := cb.state.Value()
+		state, generation, probes, successes := unpackCircuit(word)
+		switch state {
+		case CircuitClosed:
+			return generation, true
+
+		case CircuitOpen:
+			if cb.now() < cb.openedAt.Value()+int64(cb.CoolDown.Value()) {
+				return 0, false
+			}
+			// The probe counters are reset together with the state, thus
+			// only the winner of the transition starts a new round of
+			// probes.
+			cb.transition(word, packCircuit(CircuitHalfOpen, generation+1, 0, 0))
+
+		case CircuitHalfOpen:
+			if probes >= countLimit(cb.MaxProbes.Value()) {
+				return 0, false
+			}
+			if cb.state.CompareAndSwap(word, packCircuit(CircuitHalfOpen, generation, probes+1, successes)) {
+				return generation, true
+			}
+
+		case circuitOpening:
+			return 0, false
+		}
+	}
+}
+
+// Failure reports a failed call allowed in the given generation.
+// It is ignored if the circuit changed its state since.
+func (cb *CircuitBreaker) Failure(generation uint32) {
+	for {
+		word := cb.state.Value()
+		state, current, failures, _ := unpackCircuit(word)
+		if current != generation&circuitGenerationMask {
+			return
+		}
+		switch state {
+		case CircuitClosed:
+			if failures+1 >= countLimit(cb.FailureThreshold.Value()) {
+				if cb.open(word) {
+					return
+				}
+				continue
+			}
+			if cb.state.CompareAndSwap(word, packCircuit(CircuitClosed, current, failures+1, 0)) {
+				return
+			}
+
+		case CircuitHalfOpen:
+			if cb.open(word) {
+				return
+			}
+
+		default:
+			return
+		}
+	}
+}
+
+// State returns the current state.
+// An open circuit is reported as open until the next call of Allow after the
+// cool-down has elapsed.
+func (cb *CircuitBreaker) State() (state CircuitState) {
+	state, _, _, _ = unpackCircuit(cb.state.Value())
+	if state == circuitOpening {
+		return CircuitOpen
+	}
+	return state
+}
+
+// Success reports a successful call allowed in the given generation.
+// It is ignored if the circuit changed its state since.
+func (cb *CircuitBreaker) Success(generation uint32) {
+	for {
+		word := cb.state.Value()
+		state, current, count, successes := unpackCircuit(word)
+		if current != generation&circuitGenerationMask {
+			return
+		}
+		switch state {
+		case CircuitClosed:
+			// reset the consecutive failures
+			if count == 0 || cb.state.CompareAndSwap(word, packCircuit(CircuitClosed, current, 0, 0)) {
+				return
+			}
+
+		case CircuitHalfOpen:
+			// the probe finished successfully
+			if count > 0 {
+				count--
+			}
+			if successes+1 >= countLimit(cb.SuccessThreshold.Value()) {
+				if cb.transition(word, packCircuit(CircuitClosed, current+1, 0, 0)) {
+					return
+				}
+				continue
+			}
+			if cb.state.CompareAndSwap(word, packCircuit(CircuitHalfOpen, current, count, successes+1)) {
+				return
+			}
+
+		default:
+			return
+		}
+	}
+}
+
+// now returns the current time in nanoseconds.
+func (cb *CircuitBreaker) now() int64 {
+	if cb.Now != nil {
+		return cb.Now().UnixNano()
+	}
+	return time.Now().UnixNano()
+}
+
+// open transitions the circuit from the state of the given word to the open
+// state if the word is still current and reports whether it did.
+func (cb *CircuitBreaker) open(old uint64) (opened bool) {
+	from, generation, _, _ := unpackCircuit(old)
+	generation++
+
+	// Only the winner of the transition to the intermediate opening state
+	// records the opening time. The circuit rejects all calls and outcomes
+	// until it is open, thus no other goroutine changes the state word in
+	// between and the opening time is visible before the open state is.
+	if !cb.state.CompareAndSwap(old, packCircuit(circuitOpening, generation, 0, 0)) {
+		return false
+	}
+	cb.openedAt.Set(cb.now())
+	cb.state.Set(packCircuit(CircuitOpen, generation, 0, 0))
+	if cb.OnStateChange != nil {
+		cb.OnStateChange(from, CircuitOpen)
+	}
+	return true
+}
+
+// transition atomically replaces the state word if it still matches old,
+// calls the OnStateChange callback if it did and reports whether it did.
+func (cb *CircuitBreaker) transition(old, new uint64) (swapped bool) {
+	if !cb.state.CompareAndSwap(old, new) {
+		return false
+	}
+	if cb.OnStateChange != nil {
+		from, _, _, _ := unpackCircuit(old)
+		to, _, _, _ := unpackCircuit(new)
+		cb.OnStateChange(from, to)
+	}
+	return true
+}
+
+// countLimit returns v as a limit of a counter of the state word, which is at
+// least 1 and at most circuitCountMax.
+func countLimit(v uint32) uint32 {
+	if v > circuitCountMax {
+		return circuitCountMax
+	}
+	if v == 0 {
+		return 1
+	}
+	return v
+}
@@ -0,0 +1,362 @@
+package atom
+
+import (
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	c.mu.Unlock()
+}
+
+func TestCircuitState(t *testing.T) {
+	tests := map[CircuitState]string{
+		CircuitClosed:    "closed",
+		CircuitOpen:      "open",
+		CircuitHalfOpen:  "half-open",
+		CircuitState(42): "unknown",
+	}
+	for state, name := range tests {
+		if s := state.String(); s != name {
+			t.Errorf("Expected %q, got %q", name, s)
+		}
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+
+	type change struct{ from, to CircuitState }
+	var changes []change
+
+	var cb CircuitBreaker
+	cb.FailureThreshold.Set(3)
+	cb.SuccessThreshold.Set(2)
+	cb.MaxProbes.Set(2)
+	cb.CoolDown.Set(time.Second)
+	cb.Now = clock.Now
+	cb.OnStateChange = func(from, to CircuitState) {
+		changes = append(changes, change{from, to})
+	}
+
+	expectChanges := func(expected ...change) {
+		t.Helper()
+		if len(changes) != len(expected) {
+			t.Fatalf("Expected state changes %v, got %v", expected, changes)
+		}
+		for i := range expected {
+			if changes[i] != expected[i] {
+				t.Fatalf("Expected state changes %v, got %v", expected, changes)
+			}
+		}
+		changes = nil
+	}
+
+	if s := cb.State(); s != CircuitClosed {
+		t.Fatal("Expected initial state to be closed, got", s)
+	}
+
+	// failures below the threshold and interrupted by a success keep the
+	// circuit closed
+	gen, ok := cb.Allow()
+	if !ok {
+		t.Fatal("Closed circuit rejected call")
+	}
+	for i := 0; i < 2; i++ {
+		if g, ok := cb.Allow(); !ok || g != gen {
+			t.Fatal("Closed circuit rejected call or changed generation")
+		}
+		cb.Failure(gen)
+	}
+	cb.Success(gen)
+	for i := 0; i < 2; i++ {
+		cb.Failure(gen)
+	}
+	if s := cb.State(); s != CircuitClosed {
+		t.Fatal("Expected state to be closed, got", s)
+	}
+	expectChanges()
+
+	cb.Failure(gen)
+	if s := cb.State(); s != CircuitOpen {
+		t.Fatal("Expected state to be open, got", s)
+	}
+	expectChanges(change{CircuitClosed, CircuitOpen})
+
+	// outcomes of calls of the closed circuit are ignored
+	cb.Success(gen)
+	cb.Failure(gen)
+
+	if _, ok := cb.Allow(); ok {
+		t.Fatal("Open circuit allowed call")
+	}
+	clock.Advance(999 * time.Millisecond)
+	if _, ok := cb.Allow(); ok {
+		t.Fatal("Open circuit allowed call before cool-down elapsed")
+	}
+	clock.Advance(time.Millisecond)
+
+	// half-open: only MaxProbes concurrent probes are allowed
+	gen, ok = cb.Allow()
+	if g, ok2 := cb.Allow(); !ok || !ok2 || g != gen {
+		t.Fatal("Half-open circuit rejected probe")
+	}
+	if _, ok := cb.Allow(); ok {
+		t.Fatal("Half-open circuit allowed more than MaxProbes probes")
+	}
+	if s := cb.State(); s != CircuitHalfOpen {
+		t.Fatal("Expected state to be half-open, got", s)
+	}
+	expectChanges(change{CircuitOpen, CircuitHalfOpen})
+
+	// a failed probe re-opens the circuit
+	cb.Failure(gen)
+	if s := cb.State(); s != CircuitOpen {
+		t.Fatal("Expected state to be open, got", s)
+	}
+	expectChanges(change{CircuitHalfOpen, CircuitOpen})
+
+	// the outcome of the other probe of the previous round is ignored
+	cb.Success(gen)
+	if s := cb.State(); s != CircuitOpen {
+		t.Fatal("Expected state to be open, got", s)
+	}
+
+	clock.Advance(time.Second)
+	gen, ok = cb.Allow()
+	if !ok {
+		t.Fatal("Half-open circuit rejected probe")
+	}
+	cb.Success(gen)
+	if s := cb.State(); s != CircuitHalfOpen {
+		t.Fatal("Expected state to be half-open, got", s)
+	}
+	if gen, ok = cb.Allow(); !ok {
+		t.Fatal("Half-open circuit rejected probe after a finished probe")
+	}
+	cb.Success(gen)
+	if s := cb.State(); s != CircuitClosed {
+		t.Fatal("Expected state to be closed, got", s)
+	}
+	expectChanges(
+		change{CircuitOpen, CircuitHalfOpen},
+		change{CircuitHalfOpen, CircuitClosed},
+	)
+
+	// the failure count starts from scratch after closing
+	gen, _ = cb.Allow()
+	cb.Failure(gen)
+	cb.Failure(gen)
+	if s := cb.State(); s != CircuitClosed {
+		t.Fatal("Expected state to be closed, got", s)
+	}
+}
+
+func TestCircuitBreakerZeroValue(t *testing.T) {
+	var cb CircuitBreaker
+	gen, ok := cb.Allow()
+	if !ok {
+		t.Fatal("Closed circuit rejected call")
+	}
+	cb.Failure(gen)
+	if s := cb.State(); s != CircuitOpen {
+		t.Fatal("Expected state to be open, got", s)
+	}
+
+	// without a cool-down the circuit half-opens immediately
+	if gen, ok = cb.Allow(); !ok {
+		t.Fatal("Half-open circuit rejected probe")
+	}
+	if _, ok := cb.Allow(); ok {
+		t.Fatal("Half-open circuit allowed more than one probe")
+	}
+	cb.Success(gen)
+	if s := cb.State(); s != CircuitClosed {
+		t.Fatal("Expected state to be closed, got", s)
+	}
+}
+
+func TestCircuitBreakerConcurrent(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+
+	var cb CircuitBreaker
+	cb.FailureThreshold.Set(10)
+	cb.MaxProbes.Set(4)
+	cb.CoolDown.Set(time.Second)
+	cb.Now = clock.Now
+
+	var opened Uint32
+	cb.OnStateChange = func(from, to CircuitState) {
+		if to == CircuitOpen {
+			opened.Add(1)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if gen, ok := cb.Allow(); ok {
+					cb.Failure(gen)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if s := cb.State(); s != CircuitOpen {
+		t.Fatal("Expected state to be open, got", s)
+	}
+	if n := opened.Value(); n != 1 {
+		t.Fatal("Expected the circuit to open exactly once, opened", n)
+	}
+
+	clock.Advance(time.Second)
+	var allowed Uint32
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, ok := cb.Allow(); ok {
+				allowed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := allowed.Value(); n != 4 {
+		t.Fatal("Expected 4 probes to be allowed, got", n)
+	}
+}
+
+func TestCircuitBreakerConcurrentProbes(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+
+	var cb CircuitBreaker
+	cb.MaxProbes.Set(1)
+	cb.CoolDown.Set(time.Second)
+	cb.Now = func() time.Time {
+		// let other goroutines overtake between the cool-down check and
+		// the transition
+		now := clock.Now()
+		runtime.Gosched()
+		return now
+	}
+
+	gen, _ := cb.Allow()
+	for round := 0; round < 100; round++ {
+		// re-open the circuit by failing the probe of the previous round
+		cb.Failure(gen)
+		if s := cb.State(); s != CircuitOpen {
+			t.Fatal("Expected state to be open, got", s)
+		}
+		clock.Advance(time.Second)
+
+		// goroutines racing for the transition to half-open must not
+		// start more than one probe
+		var (
+			allowed Uint32
+			start   = make(chan struct{})
+			wg      sync.WaitGroup
+		)
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				<-start
+				for j := 0; j < 10; j++ {
+					if g, ok := cb.Allow(); ok {
+						gen = g
+						allowed.Add(1)
+					}
+					runtime.Gosched()
+				}
+			}()
+		}
+		close(start)
+		wg.Wait()
+		if n := allowed.Value(); n != 1 {
+			t.Fatal("Expected 1 probe to be allowed, got", n)
+		}
+	}
+}
+
+func TestCircuitBreakerStaleOutcomes(t *testing.T) {
+	var cb CircuitBreaker
+	cb.FailureThreshold.Set(1)
+	cb.SuccessThreshold.Set(3)
+	cb.MaxProbes.Set(1)
+
+	// calls allowed while the circuit is closed, which finish only after it
+	// half-opened
+	var stale [4]uint32
+	for i := range stale {
+		stale[i], _ = cb.Allow()
+	}
+	cb.Failure(stale[0])
+	probe, ok := cb.Allow()
+	if !ok {
+		t.Fatal("Half-open circuit rejected probe")
+	}
+	for _, gen := range stale[1:] {
+		cb.Success(gen)
+	}
+
+	// the stale successes neither free the probe slot nor close the circuit
+	if _, ok := cb.Allow(); ok {
+		t.Fatal("Half-open circuit allowed more than one probe")
+	}
+	if s := cb.State(); s != CircuitHalfOpen {
+		t.Fatal("Expected state to be half-open, got", s)
+	}
+	for i := 0; i < 3; i++ {
+		cb.Success(probe)
+		if probe, ok = cb.Allow(); i < 2 && !ok {
+			t.Fatal("Half-open circuit rejected probe after a finished probe")
+		}
+	}
+	if s := cb.State(); s != CircuitClosed {
+		t.Fatal("Expected state to be closed, got", s)
+	}
+
+	// a failure of the previous closed period does not count either
+	cb.Failure(stale[1])
+	if s := cb.State(); s != CircuitClosed {
+		t.Fatal("Expected state to be closed, got", s)
+	}
+}
+
+func TestCircuitBreakerOpenedAt(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+
+	var cb CircuitBreaker
+	cb.CoolDown.Set(time.Second)
+	cb.Now = clock.Now
+
+	// a goroutine which lost the transition to open must not move the
+	// opening time forward
+	gen, _ := cb.Allow()
+	cb.Failure(gen)
+	clock.Advance(time.Second)
+	cb.Failure(gen)
+	cb.open(packCircuit(CircuitClosed, gen, 0, 0))
+	if _, ok := cb.Allow(); !ok {
+		t.Fatal("Open circuit rejected probe after the cool-down elapsed")
+	}
+}
@@ -0,0 +1,86 @@
+package atom
+
+// COWMap is a copy-on-write map for read-mostly lookup tables.
+//
+// The map is immutable once published, thus reads are lock-free and as cheap
+// as a single atomic load plus the regular map lookup. Every write copies the
+// whole map and publishes the copy with a CompareAndSwap, retrying if another
+// writer published a map in the meantime. Writes are thus expensive and
+// proportional to the size of the map.
+// The zero value is an empty map.
+type COWMap[K comparable, V any] struct {
+	_ noCopy
+	m Value // holds a *map[K]V
+}
+
+// Delete deletes the value for the given key.
+func (c *COWMap[K, V]) Delete(key K) {
+	if _, ok := c.Load(key); !ok {
+		return
+	}
+	c.Update(func(m map[K]V) {
+		delete(m, key)
+	})
+}
+
+// Len returns the number of entries.
+func (c *COWMap[K, V]) Len() (n int) {
+	return len(c.load())
+}
+
+// Load returns the value stored for the given key, or the zero value if no
+// value is present. The ok result indicates whether a value was found.
+func (c *COWMap[K, V]) Load(key K) (value V, ok bool) {
+	value, ok = c.load()[key]
+	return value, ok
+}
+
+// Range calls f sequentially for each key and value of a consistent snapshot
+// of the map. If f returns false, Range stops the iteration.
+func (c *COWMap[K, V]) Range(f func(key K, value V) bool) {
+	for k, v := range c.load() {
+		if !f(k, v) {
+			return
+		}
+	}
+}
+
+// Store sets the value for the given key.
+func (c *COWMap[K, V]) Store(key K, value V) {
+	c.Update(func(m map[K]V) {
+		m[key] = value
+	})
+}
+
+// Update calls update with a private copy of the map, which may be modified
+// freely, and atomically publishes the modified copy.
+// If another writer published a map in the meantime, update is called again
+// with a fresh copy. It must thus be free of side effects and must not retain
+// the map.
+func (c *COWMap[K, V]) Update(update func(m map[K]V)) {
+	for {
+		old := c.m.Value()
+		var current map[K]V
+		if old != nil {
+			current = *old.(*map[K]V)
+		}
+
+		m := make(map[K]V, len(current)+1)
+		for k, v := range current {
+			m[k] = v
+		}
+		update(m)
+
+		if c.m.CompareAndSwap(old, &m) {
+			return
+		}
+	}
+}
+
+// load returns the current immutable map.
+func (c *COWMap[K, V]) load() map[K]V {
+	if v := c.m.Value(); v != nil {
+		return *v.(*map[K]V)
+	}
+	return nil
+}
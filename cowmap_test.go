@@ -0,0 +1,104 @@
+package atom
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestCOWMap(t *testing.T) {
+	var m COWMap[string, int]
+	if n := m.Len(); n != 0 {
+		t.Fatal("Expected initial length 0, got", n)
+	}
+	if _, ok := m.Load("a"); ok {
+		t.Fatal("Unexpected value in empty map")
+	}
+	m.Delete("a")
+
+	m.Store("a", 1)
+	m.Store("b", 2)
+	if v, ok := m.Load("a"); !ok || v != 1 {
+		t.Fatal("Expected value 1, got", v, ok)
+	}
+	if n := m.Len(); n != 2 {
+		t.Fatal("Expected length 2, got", n)
+	}
+
+	m.Update(func(m map[string]int) {
+		m["a"] += 10
+		m["c"] = 3
+	})
+	if v, _ := m.Load("a"); v != 11 {
+		t.Fatal("Expected value 11, got", v)
+	}
+
+	m.Delete("b")
+	if _, ok := m.Load("b"); ok {
+		t.Fatal("Deleted value still present")
+	}
+
+	seen := make(map[string]int)
+	m.Range(func(k string, v int) bool {
+		seen[k] = v
+		return true
+	})
+	if len(seen) != 2 || seen["a"] != 11 || seen["c"] != 3 {
+		t.Fatal("Unexpected entries:", seen)
+	}
+
+	var calls int
+	m.Range(func(k string, v int) bool {
+		calls++
+		return false
+	})
+	if calls != 1 {
+		t.Fatal("Range did not stop")
+	}
+}
+
+func TestCOWMapSnapshot(t *testing.T) {
+	var m COWMap[int, int]
+	m.Store(1, 1)
+
+	// modifications during Range do not affect the iterated snapshot
+	m.Range(func(k, v int) bool {
+		m.Store(2, 2)
+		m.Delete(1)
+		return true
+	})
+	if _, ok := m.Load(1); ok {
+		t.Fatal("Deleted value still present")
+	}
+	if v, _ := m.Load(2); v != 2 {
+		t.Fatal("Expected value 2, got", v)
+	}
+}
+
+func TestCOWMapConcurrent(t *testing.T) {
+	var m COWMap[string, int]
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				m.Store(strconv.Itoa(i*100+j), j)
+				m.Update(func(m map[string]int) {
+					m["counter"]++
+				})
+				m.Load("counter")
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// no update may get lost
+	if n := m.Len(); n != 801 {
+		t.Fatal("Expected length 801, got", n)
+	}
+	if v, _ := m.Load("counter"); v != 800 {
+		t.Fatal("Expected counter 800, got", v)
+	}
+}
@@ -0,0 +1,123 @@
+package atom
+
+// COWSlice is a copy-on-write slice for read-mostly lists, e.g. of listeners
+// or handlers.
+//
+// Load is lock-free and returns the currently published slice without
+// copying it. Every write copies the slice and publishes the copy with a
+// CompareAndSwap, retrying if another writer published a slice in the
+// meantime.
+//
+// Slices returned by Load are shared and must be treated as read-only.
+// In debug mode, every operation verifies that the published slice was not
+// modified and panics otherwise.
+// The zero value is an empty slice.
+type COWSlice[T any] struct {
+	_ noCopy
+
+	// Debug enables the detection of modifications of published slices.
+	// It must be set before the first use.
+	Debug bool
+
+	s Value // holds a *cowSliceState[T]
+}
+
+type cowSliceState[T any] struct {
+	s      []T
+	shadow []T // private copy of s, only kept in debug mode
+}
+
+// Append appends the given values.
+func (c *COWSlice[T]) Append(values ...T) {
+	c.update(func(old []T) []T {
+		s := make([]T, len(old), len(old)+len(values))
+		copy(s, old)
+		return append(s, values...)
+	})
+}
+
+// Len returns the length of the slice.
+func (c *COWSlice[T]) Len() (n int) {
+	return len(c.Load())
+}
+
+// Load returns the current slice, which must not be modified.
+// Its capacity is limited to its length, thus appending to it always copies.
+func (c *COWSlice[T]) Load() (s []T) {
+	st := c.load()
+	if st == nil {
+		return nil
+	}
+	if c.Debug {
+		c.check(st)
+	}
+	return st.s
+}
+
+// Remove removes all elements for which match returns true and returns the
+// number of removed elements.
+// match might be called multiple times per element if another writer
+// published a slice concurrently.
+func (c *COWSlice[T]) Remove(match func(value T) bool) (removed int) {
+	c.update(func(old []T) []T {
+		s := make([]T, 0, len(old))
+		for _, v := range old {
+			if !match(v) {
+				s = append(s, v)
+			}
+		}
+		removed = len(old) - len(s)
+		return s
+	})
+	return removed
+}
+
+// Replace replaces the whole slice with a copy of the given values.
+func (c *COWSlice[T]) Replace(values []T) {
+	s := make([]T, len(values))
+	copy(s, values)
+	c.update(func([]T) []T {
+		return s
+	})
+}
+
+// check panics if the published slice differs from its private copy.
+// The elements are compared by their memory, so that copied func values and
+// NaNs are considered unmodified.
+func (c *COWSlice[T]) check(st *cowSliceState[T]) {
+	if !sliceIdentical(st.s, st.shadow) {
+		panic("atom: published COWSlice was modified")
+	}
+}
+
+// load returns the current state, which is nil if nothing was published yet.
+func (c *COWSlice[T]) load() *cowSliceState[T] {
+	st, _ := c.s.Value().(*cowSliceState[T])
+	return st
+}
+
+// update publishes the slice returned by f, which must be a new slice and is
+// called with the currently published slice until the CompareAndSwap succeeds.
+func (c *COWSlice[T]) update(f func(old []T) []T) {
+	for {
+		old := c.s.Value()
+		var current []T
+		if st, _ := old.(*cowSliceState[T]); st != nil {
+			if c.Debug {
+				c.check(st)
+			}
+			current = st.s
+		}
+
+		s := f(current)
+		st := &cowSliceState[T]{s: s[:len(s):len(s)]}
+		if c.Debug {
+			st.shadow = make([]T, len(s))
+			copy(st.shadow, s)
+		}
+
+		if c.s.CompareAndSwap(old, st) {
+			return
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package atom
+
+import (
+	"math"
+	"sync"
+	"testing"
+)
+
+func TestCOWSlice(t *testing.T) {
+	var c COWSlice[int]
+	if s := c.Load(); s != nil {
+		t.Fatal("Expected initial slice to be nil, got", s)
+	}
+
+	c.Append(1, 2, 3)
+	c.Append(4)
+	s := c.Load()
+	if len(s) != 4 || s[0] != 1 || s[3] != 4 {
+		t.Fatal("Unexpected slice:", s)
+	}
+	if cap(s) != len(s) {
+		t.Fatal("Expected capacity to be limited to the length")
+	}
+
+	if n := c.Remove(func(v int) bool { return v%2 == 0 }); n != 2 {
+		t.Fatal("Expected 2 removed elements, got", n)
+	}
+	if s := c.Load(); len(s) != 2 || s[0] != 1 || s[1] != 3 {
+		t.Fatal("Unexpected slice:", s)
+	}
+
+	// previously loaded slices are not affected
+	if len(s) != 4 || s[1] != 2 {
+		t.Fatal("Published slice was modified:", s)
+	}
+
+	values := []int{5, 6}
+	c.Replace(values)
+	values[0] = 0
+	if s := c.Load(); len(s) != 2 || s[0] != 5 {
+		t.Fatal("Unexpected slice:", s)
+	}
+	if n := c.Len(); n != 2 {
+		t.Fatal("Expected length 2, got", n)
+	}
+}
+
+func TestCOWSliceDebug(t *testing.T) {
+	c := COWSlice[string]{Debug: true}
+	c.Append("a", "b")
+	c.Append("c")
+	if s := c.Load(); len(s) != 3 {
+		t.Fatal("Unexpected slice:", s)
+	}
+
+	expectPanic := func(f func()) {
+		t.Helper()
+		defer func() {
+			if recover() == nil {
+				t.Fatal("Expected panic after modification of published slice")
+			}
+		}()
+		f()
+	}
+
+	s := c.Load()
+	s[0] = "x"
+	expectPanic(func() { c.Load() })
+	expectPanic(func() { c.Append("d") })
+
+	s[0] = "a"
+	c.Append("d")
+}
+
+func TestCOWSliceDebugFunc(t *testing.T) {
+	c := COWSlice[func() int]{Debug: true}
+	c.Append(func() int { return 1 })
+	c.Append(func() int { return 2 })
+	s := c.Load()
+	if len(s) != 2 || s[0]() != 1 || s[1]() != 2 {
+		t.Fatal("Unexpected slice")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("Expected panic after modification of published slice")
+		}
+	}()
+	s[0] = s[1]
+	c.Load()
+}
+
+func TestCOWSliceDebugNaN(t *testing.T) {
+	c := COWSlice[float64]{Debug: true}
+	c.Append(math.NaN(), 1)
+	if s := c.Load(); len(s) != 2 || !math.IsNaN(s[0]) {
+		t.Fatal("Unexpected slice:", s)
+	}
+	c.Append(2)
+}
+
+func TestCOWSliceConcurrent(t *testing.T) {
+	var c COWSlice[int]
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				c.Append(i)
+				_ = c.Load()
+			}
+			c.Remove(func(v int) bool { return v == i && i%2 == 0 })
+		}(i)
+	}
+	wg.Wait()
+
+	// no update may get lost
+	if n := c.Len(); n != 400 {
+		t.Fatal("Expected length 400, got", n)
+	}
+	for _, v := range c.Load() {
+		if v%2 == 0 {
+			t.Fatal("Unexpected element", v)
+		}
+	}
+}
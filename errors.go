@@ -0,0 +1,94 @@
+package atom
+
+import (
+	"strings"
+	"sync/atomic"
+)
+
+// Errors collects errors of many goroutines, e.g. of fan-out workers.
+//
+// Append is lock-free and pushes the error onto a linked list with a
+// CompareAndSwap. Err combines the collected errors like errors.Join.
+// The zero value is an empty collector without a limit.
+type Errors struct {
+	_ noCopy
+
+	// Limit is the maximum number of retained errors. Further errors are
+	// only counted. A value of 0 means no limit.
+	// It must be set before the first use.
+	Limit int
+
+	n    Int64                      // number of appended errors
+	head atomic.Pointer[errorsNode] // error appended last
+}
+
+type errorsNode struct {
+	err  error
+	next *errorsNode
+}
+
+// Append appends the error and reports whether it was retained.
+// A nil error is ignored.
+func (e *Errors) Append(err error) (retained bool) {
+	if err == nil {
+		return false
+	}
+	if n := e.n.Add(1); e.Limit > 0 && n > int64(e.Limit) {
+		return false
+	}
+	node := &errorsNode{err: err}
+	for {
+		node.next = e.head.Load()
+		if e.head.CompareAndSwap(node.next, node) {
+			return true
+		}
+	}
+}
+
+// Count returns the number of appended errors, including those which were
+// not retained because of the limit.
+func (e *Errors) Count() (n int) {
+	return int(e.n.Value())
+}
+
+// Err returns the retained errors combined into a single error, or nil if
+// there are none. Like the result of errors.Join, its message consists of the
+// messages of all errors separated by newlines, and it has an
+// Unwrap() []error method returning all errors.
+func (e *Errors) Err() error {
+	errs := e.Errors()
+	if len(errs) == 0 {
+		return nil
+	}
+	return &joinedErrors{errs}
+}
+
+// Errors returns the retained errors in the order in which they were
+// appended.
+func (e *Errors) Errors() (errs []error) {
+	for node := e.head.Load(); node != nil; node = node.next {
+		errs = append(errs, node.err)
+	}
+	for i, j := 0, len(errs)-1; i < j; i, j = i+1, j-1 {
+		errs[i], errs[j] = errs[j], errs[i]
+	}
+	return errs
+}
+
+// joinedErrors is an error combining multiple errors, compatible with the
+// result of errors.Join.
+type joinedErrors struct {
+	errs []error
+}
+
+func (e *joinedErrors) Error() string {
+	msgs := make([]string, len(e.errs))
+	for i, err := range e.errs {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+func (e *joinedErrors) Unwrap() []error {
+	return e.errs
+}
@@ -0,0 +1,68 @@
+package atom
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestErrors(t *testing.T) {
+	var e Errors
+	if err := e.Err(); err != nil {
+		t.Fatal("Expected nil error, got", err)
+	}
+	if e.Append(nil) {
+		t.Fatal("Append retained nil error")
+	}
+
+	a, b := errors.New("a"), errors.New("b")
+	e.Append(a)
+	e.Append(b)
+	if n := e.Count(); n != 2 {
+		t.Fatal("Expected 2 errors, got", n)
+	}
+
+	err := e.Err()
+	if err == nil || err.Error() != "a\nb" {
+		t.Fatal("Unexpected error:", err)
+	}
+	errs := err.(interface{ Unwrap() []error }).Unwrap()
+	if len(errs) != 2 || errs[0] != a || errs[1] != b {
+		t.Fatal("Unexpected wrapped errors:", errs)
+	}
+}
+
+func TestErrorsLimit(t *testing.T) {
+	e := Errors{Limit: 2}
+	for i := 0; i < 5; i++ {
+		if retained := e.Append(errors.New("test")); retained != (i < 2) {
+			t.Fatal("Unexpected retention of error", i)
+		}
+	}
+	if n := e.Count(); n != 5 {
+		t.Fatal("Expected 5 errors, got", n)
+	}
+	if errs := e.Errors(); len(errs) != 2 {
+		t.Fatal("Expected 2 retained errors, got", len(errs))
+	}
+}
+
+func TestErrorsConcurrent(t *testing.T) {
+	var e Errors
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				e.Append(errors.New("test"))
+			}
+		}()
+	}
+	wg.Wait()
+
+	if errs := e.Errors(); len(errs) != 800 {
+		t.Fatal("Expected 800 errors, got", len(errs))
+	}
+}
@@ -1,3 +1,3 @@
 module github.com/julienschmidt/atom
 
-go 1.4
+go 1.19
@@ -0,0 +1,151 @@
+package atom
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrClockDrift is returned by HLC.Update if a remote timestamp is too far
+// ahead of the local clock.
+var ErrClockDrift = errors.New("remote clock drift exceeds maximum")
+
+const hlcLogicalBits = 16
+
+// HLCTimestamp is a timestamp of a hybrid logical clock.
+// It consists of 48 bits of physical time in milliseconds since the Unix epoch
+// and a 16-bit logical counter. Timestamps are ordered like their uint64
+// values.
+type HLCTimestamp uint64
+
+func newHLCTimestamp(wall, logical uint64) HLCTimestamp {
+	if logical >= 1<<hlcLogicalBits {
+		// the logical counter is used up, move the physical time forward
+		wall++
+		logical = 0
+	}
+	return HLCTimestamp(wall<<hlcLogicalBits | logical)
+}
+
+// Logical returns the logical counter of the timestamp.
+func (ts HLCTimestamp) Logical() (logical uint16) {
+	return uint16(ts)
+}
+
+// MarshalBinary encodes the timestamp into 8 bytes in big-endian order, so
+// that encoded timestamps sort like the timestamps themselves.
+func (ts HLCTimestamp) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, 8)
+	binary.BigEndian.PutUint64(data, uint64(ts))
+	return data, nil
+}
+
+// String returns the timestamp in the form "<time>+<logical>".
+func (ts HLCTimestamp) String() string {
+	return fmt.Sprintf("%s+%d", ts.Time().UTC().Format(time.RFC3339Nano), ts.Logical())
+}
+
+// Time returns the physical time of the timestamp.
+func (ts HLCTimestamp) Time() (t time.Time) {
+	return time.UnixMilli(int64(ts.wall()))
+}
+
+// UnmarshalBinary decodes a timestamp encoded by MarshalBinary.
+func (ts *HLCTimestamp) UnmarshalBinary(data []byte) error {
+	if len(data) != 8 {
+		return fmt.Errorf("atom: invalid HLCTimestamp length %d", len(data))
+	}
+	*ts = HLCTimestamp(binary.BigEndian.Uint64(data))
+	return nil
+}
+
+func (ts HLCTimestamp) wall() uint64 {
+	return uint64(ts) >> hlcLogicalBits
+}
+
+// HLC is a lock-free hybrid logical clock, which combines physical time with a
+// logical counter. Its timestamps are close to the physical time, but
+// consistent with causality: a timestamp generated after receiving a remote
+// timestamp is always greater than the remote one, even if the remote clock is
+// ahead of the local one.
+//
+// The last timestamp is kept in a single Uint64, which Now and Update advance
+// with a CompareAndSwap.
+// The zero value is a clock without a drift limit using time.Now.
+// MaxDrift and Clock must be set before the first use.
+type HLC struct {
+	_ noCopy
+
+	// MaxDrift is the maximum time a remote timestamp may be ahead of the
+	// local physical time. If zero, the drift is not limited.
+	MaxDrift time.Duration
+
+	// Clock returns the current physical time. If nil, time.Now is used.
+	Clock func() time.Time
+
+	last Uint64
+}
+
+// Now returns a new timestamp for a local or send event, which is greater
+// than all timestamps returned or witnessed before.
+func (c *HLC) Now() (ts HLCTimestamp) {
+	for {
+		old := HLCTimestamp(c.last.Value())
+		ts = newHLCTimestamp(c.physical(), 0)
+		if ts <= old {
+			ts = newHLCTimestamp(old.wall(), uint64(old.Logical())+1)
+		}
+		if c.last.CompareAndSwap(uint64(old), uint64(ts)) {
+			return ts
+		}
+	}
+}
+
+// Update witnesses the remote timestamp of a receive event and returns a new
+// timestamp, which is greater than both the remote timestamp and all
+// timestamps returned or witnessed before.
+// It returns ErrClockDrift and leaves the clock unchanged if the remote
+// timestamp is more than MaxDrift ahead of the local physical time.
+func (c *HLC) Update(remote HLCTimestamp) (ts HLCTimestamp, err error) {
+	pt := c.physical()
+	if c.MaxDrift > 0 && remote.wall() > pt {
+		if drift := time.Duration(remote.wall()-pt) * time.Millisecond; drift > c.MaxDrift {
+			return 0, fmt.Errorf("atom: remote timestamp %v ahead by %v: %w", remote, drift, ErrClockDrift)
+		}
+	}
+
+	for {
+		old := HLCTimestamp(c.last.Value())
+		max := old
+		if remote > max {
+			max = remote
+		}
+		ts = newHLCTimestamp(pt, 0)
+		if ts <= max {
+			ts = newHLCTimestamp(max.wall(), uint64(max.Logical())+1)
+		}
+		if c.last.CompareAndSwap(uint64(old), uint64(ts)) {
+			return ts, nil
+		}
+	}
+}
+
+// Value returns the last timestamp without advancing the clock.
+func (c *HLC) Value() (ts HLCTimestamp) {
+	return HLCTimestamp(c.last.Value())
+}
+
+// physical returns the current physical time in milliseconds since the Unix
+// epoch, truncated to the 48 bits of a timestamp.
+func (c *HLC) physical() uint64 {
+	now := time.Now
+	if c.Clock != nil {
+		now = c.Clock
+	}
+	ms := now().UnixMilli()
+	if ms < 0 {
+		return 0
+	}
+	return uint64(ms) & (1<<(64-hlcLogicalBits) - 1)
+}
@@ -0,0 +1,132 @@
+package atom
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestHLCTimestamp(t *testing.T) {
+	ts := newHLCTimestamp(1500, 7)
+	if tm := ts.Time(); !tm.Equal(time.Unix(1, 500e6)) {
+		t.Fatal("Unexpected time", tm)
+	}
+	if l := ts.Logical(); l != 7 {
+		t.Fatal("Expected logical 7, got", l)
+	}
+	if s := ts.String(); s != "1970-01-01T00:00:01.5Z+7" {
+		t.Fatal("Unexpected string", s)
+	}
+
+	// the logical counter carries over into the physical time
+	if ts := newHLCTimestamp(1500, 1<<16); ts.Time() != time.UnixMilli(1501) || ts.Logical() != 0 {
+		t.Fatal("Unexpected timestamp", ts)
+	}
+
+	data, err := ts.MarshalBinary()
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	later, _ := newHLCTimestamp(1500, 8).MarshalBinary()
+	if bytes.Compare(data, later) >= 0 {
+		t.Fatal("Encoded timestamps are not ordered")
+	}
+
+	var decoded HLCTimestamp
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if decoded != ts {
+		t.Fatal("Expected", ts, "got", decoded)
+	}
+	if err := decoded.UnmarshalBinary(data[1:]); err == nil {
+		t.Fatal("Expected error for invalid length")
+	}
+}
+
+func TestHLC(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	c := HLC{MaxDrift: time.Second, Clock: clock.Now}
+
+	ts1 := c.Now()
+	if ts1.Time() != time.Unix(1000, 0) || ts1.Logical() != 0 {
+		t.Fatal("Unexpected timestamp", ts1)
+	}
+	ts2 := c.Now()
+	if ts2.Time() != time.Unix(1000, 0) || ts2.Logical() != 1 {
+		t.Fatal("Unexpected timestamp", ts2)
+	}
+	if c.Value() != ts2 {
+		t.Fatal("Value does not match the last timestamp")
+	}
+
+	// the physical time moved forward
+	clock.Advance(time.Millisecond)
+	if ts := c.Now(); ts.Time() != time.Unix(1000, 1e6) || ts.Logical() != 0 {
+		t.Fatal("Unexpected timestamp", ts)
+	}
+
+	// the physical time moved backwards
+	clock.Advance(-time.Second)
+	if ts := c.Now(); ts.Time() != time.Unix(1000, 1e6) || ts.Logical() != 1 {
+		t.Fatal("Unexpected timestamp", ts)
+	}
+	clock.Advance(time.Second)
+
+	// a remote clock ahead of the local one
+	remote := newHLCTimestamp(1000500, 3)
+	ts, err := c.Update(remote)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if ts != newHLCTimestamp(1000500, 4) {
+		t.Fatal("Unexpected timestamp", ts)
+	}
+	if ts := c.Now(); ts != newHLCTimestamp(1000500, 5) {
+		t.Fatal("Unexpected timestamp", ts)
+	}
+
+	// a remote clock behind the local one
+	if ts, _ := c.Update(newHLCTimestamp(1000, 0)); ts != newHLCTimestamp(1000500, 6) {
+		t.Fatal("Unexpected timestamp", ts)
+	}
+
+	// a remote clock too far ahead
+	last := c.Value()
+	_, err = c.Update(newHLCTimestamp(1002000, 0))
+	if !errors.Is(err, ErrClockDrift) {
+		t.Fatal("Expected ErrClockDrift, got", err)
+	}
+	if c.Value() != last {
+		t.Fatal("Clock changed by rejected update")
+	}
+}
+
+func TestHLCConcurrent(t *testing.T) {
+	var c HLC
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var last HLCTimestamp
+			for j := 0; j < 1000; j++ {
+				var ts HLCTimestamp
+				if j%2 == 0 {
+					ts = c.Now()
+				} else {
+					ts, _ = c.Update(last)
+				}
+				if ts <= last {
+					t.Error("Timestamps are not increasing:", last, ts)
+					return
+				}
+				last = ts
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,37 @@
+package atom
+
+// LamportClock is a lock-free Lamport clock, a logical clock which orders
+// events consistently with causality: if an event happened before another
+// one, its time is lower.
+// The zero value is a clock at time 0.
+type LamportClock struct {
+	_    noCopy
+	time Uint64
+}
+
+// Tick advances the clock for a local or send event and returns the new time.
+func (c *LamportClock) Tick() (time uint64) {
+	return c.time.Add(1)
+}
+
+// Value returns the current time without advancing the clock.
+func (c *LamportClock) Value() (time uint64) {
+	return c.time.Value()
+}
+
+// Witness advances the clock past the remote time of a receive event and
+// returns the new time, which is greater than both the remote time and the
+// previous local time.
+func (c *LamportClock) Witness(remote uint64) (time uint64) {
+	for {
+		old := c.time.Value()
+		time = old
+		if remote > time {
+			time = remote
+		}
+		time++
+		if c.time.CompareAndSwap(old, time) {
+			return time
+		}
+	}
+}
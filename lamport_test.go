@@ -0,0 +1,49 @@
+package atom
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestLamportClock(t *testing.T) {
+	var c LamportClock
+	if v := c.Tick(); v != 1 {
+		t.Fatal("Expected time 1, got", v)
+	}
+	if v := c.Witness(5); v != 6 {
+		t.Fatal("Expected time 6, got", v)
+	}
+	if v := c.Witness(2); v != 7 {
+		t.Fatal("Expected time 7, got", v)
+	}
+	if v := c.Value(); v != 7 {
+		t.Fatal("Expected time 7, got", v)
+	}
+}
+
+func TestLamportClockConcurrent(t *testing.T) {
+	var c LamportClock
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var last uint64
+			for j := 0; j < 1000; j++ {
+				var v uint64
+				if i%2 == 0 {
+					v = c.Tick()
+				} else {
+					v = c.Witness(last + 2)
+				}
+				if v <= last {
+					t.Error("Time is not increasing:", last, v)
+					return
+				}
+				last = v
+			}
+		}(i)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,58 @@
+package atom
+
+import (
+	"context"
+)
+
+// Latch is a countdown latch. Goroutines may wait until the count reached
+// zero, e.g. until a set of operations has completed.
+// Unlike sync.WaitGroup, waiting can be aborted with a context and counting
+// down below zero is a no-op.
+type Latch struct {
+	_     noCopy
+	count Uint32
+}
+
+// NewLatch returns a new Latch with the given count.
+// It panics if count is negative or does not fit into 32 bits.
+func NewLatch(count int) *Latch {
+	if count < 0 || uint64(count) > uint64(^uint32(0)) {
+		panic("atom: invalid latch count")
+	}
+	l := &Latch{}
+	l.count.Set(uint32(count))
+	return l
+}
+
+// Count returns the current count.
+func (l *Latch) Count() (count int) {
+	return int(l.count.Value())
+}
+
+// CountDown decrements the count, unless it is already zero, and returns the
+// new count. Waiting goroutines are released when the count reaches zero.
+func (l *Latch) CountDown() (count int) {
+	for {
+		c := l.count.Value()
+		if c == 0 {
+			return 0
+		}
+		if l.count.CompareAndSwap(c, c-1) {
+			return int(c - 1)
+		}
+	}
+}
+
+// TryWait reports whether the count reached zero without blocking.
+func (l *Latch) TryWait() (done bool) {
+	return l.count.Value() == 0
+}
+
+// Wait blocks until the count reached zero or the context is done.
+// It returns the context's error in the latter case.
+func (l *Latch) Wait(ctx context.Context) error {
+	_, err := l.count.WaitUntil(ctx, func(count uint32) bool {
+		return count == 0
+	})
+	return err
+}
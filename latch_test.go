@@ -0,0 +1,63 @@
+package atom
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLatch(t *testing.T) {
+	l := NewLatch(3)
+	if c := l.Count(); c != 3 {
+		t.Fatal("Expected initial count to be 3, got", c)
+	}
+	if l.TryWait() {
+		t.Fatal("TryWait succeeded before the count reached zero")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(ctx); err != context.DeadlineExceeded {
+		t.Fatal("Expected context.DeadlineExceeded, got", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := l.Wait(context.Background()); err != nil {
+				t.Error("Unexpected error:", err)
+			}
+		}()
+	}
+
+	if c := l.CountDown(); c != 2 {
+		t.Fatal("Expected count 2, got", c)
+	}
+	if c := l.CountDown(); c != 1 {
+		t.Fatal("Expected count 1, got", c)
+	}
+	if c := l.CountDown(); c != 0 {
+		t.Fatal("Expected count 0, got", c)
+	}
+	wg.Wait()
+
+	// counting down below zero is a no-op
+	if c := l.CountDown(); c != 0 {
+		t.Fatal("Expected count 0, got", c)
+	}
+	if !l.TryWait() {
+		t.Fatal("TryWait failed after the count reached zero")
+	}
+}
+
+func TestNewLatchPanic(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("Expected panic for negative count")
+		}
+	}()
+	NewLatch(-1)
+}
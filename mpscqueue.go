@@ -0,0 +1,120 @@
+package atom
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// MPSCQueue is an unbounded lock-free multi-producer single-consumer FIFO
+// queue based on Dmitry Vyukov's node-based MPSC queue.
+//
+// Enqueue is safe for concurrent use by any number of goroutines. Enqueue
+// never blocks and consists of an atomic swap and store. All other methods must
+// only be called by a single consumer goroutine at a time.
+//
+// An Enqueue which is in progress concurrently might not be visible to the
+// consumer yet, even if Enqueues which started later already are.
+// The zero value is an empty queue.
+type MPSCQueue[T any] struct {
+	_       noCopy
+	head    atomic.Pointer[mpscNode[T]] // node last enqueued
+	waiting Bool                        // whether the consumer is parked in DequeueWait
+	stub    mpscNode[T]                 // initial dummy node
+	tail    *mpscNode[T]                // dummy node in front of the next node to dequeue
+}
+
+// The nodes are linked with the typed atomic.Pointer, as the Pointer wrapper
+// requires unsafe and Value a type assertion on every access.
+type mpscNode[T any] struct {
+	next  atomic.Pointer[mpscNode[T]]
+	value T
+}
+
+// Dequeue removes and returns the first element. The ok result is false if
+// the queue is empty.
+func (q *MPSCQueue[T]) Dequeue() (value T, ok bool) {
+	tail := q.tail
+	if tail == nil {
+		tail = &q.stub
+	}
+	next := tail.next.Load()
+	if next == nil {
+		return value, false
+	}
+
+	// next becomes the new dummy node
+	q.tail = next
+	value = next.value
+	var zero T
+	next.value = zero // allow the value to be garbage collected
+	return value, true
+}
+
+// DequeueBatch removes up to len(buf) elements, stores them in buf and
+// returns their number.
+func (q *MPSCQueue[T]) DequeueBatch(buf []T) (n int) {
+	for n < len(buf) {
+		value, ok := q.Dequeue()
+		if !ok {
+			break
+		}
+		buf[n] = value
+		n++
+	}
+	return n
+}
+
+// DequeueWait removes and returns the first element, blocking until an
+// element is available or the context is done. It returns the context's
+// error in the latter case. The consumer is only parked if the queue is
+// empty.
+func (q *MPSCQueue[T]) DequeueWait(ctx context.Context) (value T, err error) {
+	if value, ok := q.Dequeue(); ok {
+		return value, nil
+	}
+
+	for {
+		// The first Enqueue which observes the flag resets it and wakes up
+		// the consumer, all others skip the notification. The flag is thus
+		// set again before every check, as the wakeup might have been caused
+		// by an Enqueue which was overtaken by another, not yet linked one.
+		err = park(ctx, q, func() bool {
+			q.waiting.Set(true)
+			return !q.Empty()
+		})
+		q.waiting.Set(false)
+		if err != nil {
+			return value, err
+		}
+		if value, ok := q.Dequeue(); ok {
+			return value, nil
+		}
+	}
+}
+
+// Empty reports whether the queue is empty.
+func (q *MPSCQueue[T]) Empty() (empty bool) {
+	tail := q.tail
+	if tail == nil {
+		tail = &q.stub
+	}
+	next := tail.next.Load()
+	return next == nil
+}
+
+// Enqueue appends the value to the end of the queue.
+func (q *MPSCQueue[T]) Enqueue(value T) {
+	node := &mpscNode[T]{value: value}
+	prev := q.head.Swap(node)
+	if prev == nil {
+		prev = &q.stub
+	}
+
+	// Between the swap and linking the previous node, the consumer can not
+	// reach the new node yet.
+	prev.next.Store(node)
+
+	if q.waiting.Value() && q.waiting.CompareAndSwap(true, false) {
+		notify(q)
+	}
+}
@@ -0,0 +1,152 @@
+package atom
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMPSCQueue(t *testing.T) {
+	var q MPSCQueue[int]
+	if !q.Empty() {
+		t.Fatal("Expected initial queue to be empty")
+	}
+	if _, ok := q.Dequeue(); ok {
+		t.Fatal("Dequeue succeeded on empty queue")
+	}
+
+	for i := 1; i <= 5; i++ {
+		q.Enqueue(i)
+	}
+	if q.Empty() {
+		t.Fatal("Queue empty after Enqueue")
+	}
+	if v, ok := q.Dequeue(); !ok || v != 1 {
+		t.Fatal("Expected 1, got", v, ok)
+	}
+
+	buf := make([]int, 3)
+	if n := q.DequeueBatch(buf); n != 3 || buf[0] != 2 || buf[2] != 4 {
+		t.Fatal("Unexpected batch:", buf[:n])
+	}
+	if n := q.DequeueBatch(buf); n != 1 || buf[0] != 5 {
+		t.Fatal("Unexpected batch:", buf[:n])
+	}
+	if !q.Empty() {
+		t.Fatal("Expected queue to be empty")
+	}
+
+	q.Enqueue(6)
+	if v, ok := q.Dequeue(); !ok || v != 6 {
+		t.Fatal("Expected 6, got", v, ok)
+	}
+}
+
+func TestMPSCQueueDequeueWait(t *testing.T) {
+	var q MPSCQueue[string]
+
+	q.Enqueue("a")
+	if v, err := q.DequeueWait(context.Background()); err != nil || v != "a" {
+		t.Fatal("Unexpected result:", v, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	if _, err := q.DequeueWait(ctx); err != context.DeadlineExceeded {
+		t.Fatal("Expected context.DeadlineExceeded, got", err)
+	}
+	if q.waiting.Value() {
+		t.Fatal("Consumer still marked as waiting")
+	}
+
+	done := make(chan string)
+	go func() {
+		v, err := q.DequeueWait(context.Background())
+		if err != nil {
+			t.Error("Unexpected error:", err)
+		}
+		done <- v
+	}()
+	for !q.waiting.Value() {
+		time.Sleep(time.Millisecond)
+	}
+	q.Enqueue("b")
+	if v := <-done; v != "b" {
+		t.Fatal("Expected b, got", v)
+	}
+}
+
+func TestMPSCQueueConcurrent(t *testing.T) {
+	const (
+		producers   = 8
+		perProducer = 1000
+	)
+	var q MPSCQueue[int]
+
+	var wg sync.WaitGroup
+	for i := 0; i < producers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < perProducer; j++ {
+				q.Enqueue(i*perProducer + j)
+			}
+		}(i)
+	}
+
+	// the elements of each producer must be dequeued in order
+	var next [producers]int
+	for n := 0; n < producers*perProducer; n++ {
+		v, err := q.DequeueWait(context.Background())
+		if err != nil {
+			t.Fatal("Unexpected error:", err)
+		}
+		p, seq := v/perProducer, v%perProducer
+		if seq != next[p] {
+			t.Fatalf("Expected %d from producer %d, got %d", next[p], p, seq)
+		}
+		next[p]++
+	}
+	wg.Wait()
+
+	if !q.Empty() {
+		t.Fatal("Expected queue to be empty")
+	}
+}
+
+func BenchmarkMPSCQueue(b *testing.B) {
+	var q MPSCQueue[int]
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < b.N; i++ {
+			q.DequeueWait(context.Background())
+		}
+	}()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			q.Enqueue(1)
+		}
+	})
+	wg.Wait()
+}
+
+func BenchmarkMPSCChannel(b *testing.B) {
+	ch := make(chan int, 1024)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < b.N; i++ {
+			<-ch
+		}
+	}()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			ch <- 1
+		}
+	})
+	wg.Wait()
+}
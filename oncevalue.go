@@ -0,0 +1,63 @@
+package atom
+
+import (
+	"sync"
+)
+
+// OnceValue runs a function only once and caches its result and error, like
+// a sync.Once returning a value.
+//
+// Once the result is cached, Do and Done only perform a single atomic load.
+// Concurrent calls of Do wait until the running function returned.
+// The zero value is ready to use.
+type OnceValue[T any] struct {
+	_ noCopy
+
+	// Retry makes Do call the function again after it returned an error,
+	// instead of caching the error. It must be set before the first use.
+	Retry bool
+
+	done  Uint32 // set to 1 once the result is cached
+	mu    sync.Mutex
+	value T
+	err   error
+}
+
+// Do calls f, unless a result is already cached, and returns the result of f
+// or the cached one. Calls of Do are serialized until a result is cached, and
+// the result of the first f that runs is cached, regardless of which call of
+// Do passed it. If Do is called with different functions, it is thus
+// undefined which one runs.
+// If f returns an error and Retry is set, the result is returned but not
+// cached, and the f of the next call of Do runs.
+// If f panics, Do does not cache a result either.
+// f must not call Do of the same OnceValue, as this would deadlock.
+func (o *OnceValue[T]) Do(f func() (T, error)) (value T, err error) {
+	if o.done.Value() == 1 {
+		return o.value, o.err
+	}
+	return o.doSlow(f)
+}
+
+// Done reports whether a result is cached.
+func (o *OnceValue[T]) Done() (done bool) {
+	return o.done.Value() == 1
+}
+
+func (o *OnceValue[T]) doSlow(f func() (T, error)) (value T, err error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.done.Value() == 1 {
+		return o.value, o.err
+	}
+
+	value, err = f()
+	if err != nil && o.Retry {
+		return value, err
+	}
+	o.value, o.err = value, err
+	// The result must be visible before done is, as the fast path of Do
+	// reads it without locking.
+	o.done.Set(1)
+	return value, err
+}
@@ -0,0 +1,123 @@
+package atom
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestOnceValue(t *testing.T) {
+	var o OnceValue[int]
+	if o.Done() {
+		t.Fatal("Done before the first call")
+	}
+
+	var calls int
+	f := func() (int, error) {
+		calls++
+		return 42, nil
+	}
+	for i := 0; i < 3; i++ {
+		if v, err := o.Do(f); v != 42 || err != nil {
+			t.Fatal("Unexpected result:", v, err)
+		}
+	}
+	if calls != 1 {
+		t.Fatal("Expected one call, got", calls)
+	}
+	if !o.Done() {
+		t.Fatal("Not done after the first call")
+	}
+}
+
+func TestOnceValueError(t *testing.T) {
+	errTest := errors.New("test")
+	var calls int
+	f := func() (string, error) {
+		calls++
+		if calls == 1 {
+			return "", errTest
+		}
+		return "ok", nil
+	}
+
+	// the error is cached
+	var o OnceValue[string]
+	for i := 0; i < 2; i++ {
+		if _, err := o.Do(f); err != errTest {
+			t.Fatal("Expected cached error, got", err)
+		}
+	}
+	if !o.Done() || calls != 1 {
+		t.Fatal("Error was not cached")
+	}
+
+	// retry after an error
+	calls = 0
+	o2 := OnceValue[string]{Retry: true}
+	if _, err := o2.Do(f); err != errTest {
+		t.Fatal("Expected error, got", err)
+	}
+	if o2.Done() {
+		t.Fatal("Done after an error with Retry")
+	}
+	if v, err := o2.Do(f); v != "ok" || err != nil {
+		t.Fatal("Unexpected result:", v, err)
+	}
+	if v, err := o2.Do(f); v != "ok" || err != nil || calls != 2 {
+		t.Fatal("Unexpected result:", v, err, calls)
+	}
+}
+
+func TestOnceValuePanic(t *testing.T) {
+	var o OnceValue[int]
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("Expected panic")
+			}
+		}()
+		o.Do(func() (int, error) { panic("test") })
+	}()
+	if o.Done() {
+		t.Fatal("Done after a panic")
+	}
+	if v, err := o.Do(func() (int, error) { return 1, nil }); v != 1 || err != nil {
+		t.Fatal("Unexpected result:", v, err)
+	}
+}
+
+func TestOnceValueConcurrent(t *testing.T) {
+	var o OnceValue[int]
+	var calls Int32
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := o.Do(func() (int, error) {
+				calls.Add(1)
+				return 42, nil
+			})
+			if v != 42 || err != nil {
+				t.Error("Unexpected result:", v, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := calls.Value(); n != 1 {
+		t.Fatal("Expected one call, got", n)
+	}
+}
+
+func BenchmarkOnceValue(b *testing.B) {
+	var o OnceValue[int]
+	f := func() (int, error) { return 42, nil }
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			o.Do(f)
+		}
+	})
+}
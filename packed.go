@@ -0,0 +1,191 @@
+package atom
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrFieldOverflow is returned when a value is too wide for its field.
+	ErrFieldOverflow = errors.New("value too wide for field")
+
+	// ErrUnknownField is returned when a field name is not part of a layout.
+	ErrUnknownField = errors.New("unknown field")
+)
+
+// Field describes a field of a packed 64-bit word.
+type Field struct {
+	Name   string
+	Offset uint // offset of the lowest bit of the field
+	Width  uint // number of bits of the field
+}
+
+func (f *Field) mask() uint64 {
+	return (1<<f.Width - 1) << f.Offset
+}
+
+// Layout is an immutable layout of fields within a packed 64-bit word.
+type Layout struct {
+	fields []Field
+}
+
+// NewLayout returns a new Layout of the given fields.
+// It returns an error if a field name is used twice, if a field has a width
+// of zero, exceeds the 64 bits of the word or overlaps with another field.
+func NewLayout(fields ...Field) (*Layout, error) {
+	var used uint64
+	for i := range fields {
+		f := &fields[i]
+		if f.Width == 0 || f.Offset >= 64 || f.Width > 64-f.Offset {
+			return nil, fmt.Errorf("atom: field %q does not fit into 64 bits", f.Name)
+		}
+		for j := 0; j < i; j++ {
+			if fields[j].Name == f.Name {
+				return nil, fmt.Errorf("atom: duplicate field %q", f.Name)
+			}
+		}
+		if used&f.mask() != 0 {
+			return nil, fmt.Errorf("atom: field %q overlaps with another field", f.Name)
+		}
+		used |= f.mask()
+	}
+
+	l := &Layout{fields: make([]Field, len(fields))}
+	copy(l.fields, fields)
+	return l, nil
+}
+
+// Fields returns the fields of the layout.
+func (l *Layout) Fields() (fields []Field) {
+	fields = make([]Field, len(l.fields))
+	copy(fields, l.fields)
+	return fields
+}
+
+func (l *Layout) field(name string) (*Field, error) {
+	for i := range l.fields {
+		if l.fields[i].Name == name {
+			return &l.fields[i], nil
+		}
+	}
+	return nil, fmt.Errorf("atom: field %q: %w", name, ErrUnknownField)
+}
+
+// View is a decoded snapshot of a packed 64-bit word.
+// Modifications of a View only affect the snapshot.
+type View struct {
+	layout *Layout
+	word   uint64
+}
+
+// Get returns the value of the named field.
+// It panics if the field is not part of the layout.
+func (v View) Get(name string) (value uint64) {
+	f, err := v.layout.field(name)
+	if err != nil {
+		panic(err)
+	}
+	return (v.word & f.mask()) >> f.Offset
+}
+
+// Set sets the value of the named field. It returns an error if the field is
+// not part of the layout or if the value is too wide for the field.
+func (v *View) Set(name string, value uint64) error {
+	f, err := v.layout.field(name)
+	if err != nil {
+		return err
+	}
+	if f.Width < 64 && value>>f.Width != 0 {
+		return fmt.Errorf("atom: value %d for field %q of width %d: %w", value, name, f.Width, ErrFieldOverflow)
+	}
+	v.word = v.word&^f.mask() | value<<f.Offset
+	return nil
+}
+
+// Word returns the packed 64-bit word.
+func (v View) Word() (word uint64) {
+	return v.word
+}
+
+// Packed64 is a 64-bit word of multiple fields, which can be updated together
+// atomically, e.g. a version and an index or a state and a count.
+type Packed64 struct {
+	_      noCopy
+	layout *Layout
+	word   Uint64
+}
+
+// NewPacked64 returns a new Packed64 with the given layout and all fields set
+// to zero.
+func NewPacked64(layout *Layout) *Packed64 {
+	return &Packed64{layout: layout}
+}
+
+// CompareAndSwap atomically sets the new view only if the current word
+// matches the given old view and returns whether the new view was set.
+func (p *Packed64) CompareAndSwap(old, new View) (swapped bool) {
+	return p.word.CompareAndSwap(old.word, new.word)
+}
+
+// CompareAndSwapFields atomically sets the fields of new only if the fields
+// of expected match their current values. Fields not named in either map
+// are preserved, concurrent changes of them do not make the swap fail.
+// It returns an error if a field is not part of the layout or a new value is
+// too wide for its field.
+func (p *Packed64) CompareAndSwapFields(expected, new map[string]uint64) (swapped bool, err error) {
+	for {
+		old := p.Load()
+		for name, value := range expected {
+			f, err := p.layout.field(name)
+			if err != nil {
+				return false, err
+			}
+			if (old.word&f.mask())>>f.Offset != value {
+				return false, nil
+			}
+		}
+
+		v := old
+		for name, value := range new {
+			if err := v.Set(name, value); err != nil {
+				return false, err
+			}
+		}
+		if p.word.CompareAndSwap(old.word, v.word) {
+			return true, nil
+		}
+	}
+}
+
+// Layout returns the layout of the word.
+func (p *Packed64) Layout() (layout *Layout) {
+	return p.layout
+}
+
+// Load returns a decoded view of the current word.
+func (p *Packed64) Load() (view View) {
+	return View{p.layout, p.word.Value()}
+}
+
+// Store sets the word to the given view regardless of the previous value.
+func (p *Packed64) Store(view View) {
+	p.word.Set(view.word)
+}
+
+// UpdateFields atomically applies update to a view of the current word and
+// returns the new view. If the word was changed concurrently, update is
+// called again with a fresh view. It must thus be free of side effects.
+// If update returns an error, the word is left unchanged and the error is
+// returned.
+func (p *Packed64) UpdateFields(update func(view *View) error) (new View, err error) {
+	for {
+		old := p.Load()
+		new = old
+		if err := update(&new); err != nil {
+			return old, err
+		}
+		if p.word.CompareAndSwap(old.word, new.word) {
+			return new, nil
+		}
+	}
+}
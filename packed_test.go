@@ -0,0 +1,169 @@
+package atom
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestLayout(t *testing.T) {
+	invalid := [][]Field{
+		{{"a", 0, 0}},
+		{{"a", 60, 5}},
+		{{"a", 0, 65}},
+		{{"a", 64, 0}},
+		{{"a", 64, 1}},
+		{{"a", ^uint(0), 2}},
+		{{"a", 0, 8}, {"a", 8, 8}},
+		{{"a", 0, 8}, {"b", 7, 8}},
+	}
+	for _, fields := range invalid {
+		if _, err := NewLayout(fields...); err == nil {
+			t.Error("Expected error for invalid layout", fields)
+		}
+	}
+
+	fields := []Field{{"version", 48, 16}, {"index", 0, 48}}
+	l, err := NewLayout(fields...)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	fields[0].Name = "modified"
+	if f := l.Fields(); len(f) != 2 || f[0].Name != "version" {
+		t.Fatal("Unexpected fields:", f)
+	}
+
+	if _, err := NewLayout(Field{"all", 0, 64}); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+}
+
+func TestView(t *testing.T) {
+	l, _ := NewLayout(Field{"state", 0, 2}, Field{"count", 2, 30}, Field{"all", 32, 32})
+	p := NewPacked64(l)
+	if p.Layout() != l {
+		t.Fatal("Layout does not match")
+	}
+
+	v := p.Load()
+	if err := v.Set("state", 3); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if err := v.Set("count", 1<<30-1); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if err := v.Set("all", 1<<32-1); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if w := v.Word(); w != ^uint64(0) {
+		t.Fatalf("Unexpected word %x", w)
+	}
+	if err := v.Set("state", 1); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if s, c := v.Get("state"), v.Get("count"); s != 1 || c != 1<<30-1 {
+		t.Fatal("Unexpected field values:", s, c)
+	}
+
+	if err := v.Set("state", 4); !errors.Is(err, ErrFieldOverflow) {
+		t.Fatal("Expected ErrFieldOverflow, got", err)
+	}
+	if err := v.Set("missing", 0); !errors.Is(err, ErrUnknownField) {
+		t.Fatal("Expected ErrUnknownField, got", err)
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("Expected panic for unknown field")
+			}
+		}()
+		v.Get("missing")
+	}()
+
+	// views are snapshots
+	if w := p.Load().Word(); w != 0 {
+		t.Fatal("Word changed")
+	}
+	p.Store(v)
+	if s := p.Load().Get("state"); s != 1 {
+		t.Fatal("Expected state 1, got", s)
+	}
+}
+
+func TestPacked64(t *testing.T) {
+	l, _ := NewLayout(Field{"version", 48, 16}, Field{"index", 0, 48})
+	p := NewPacked64(l)
+
+	old := p.Load()
+	v := old
+	v.Set("index", 42)
+	if !p.CompareAndSwap(old, v) {
+		t.Fatal("CompareAndSwap did not report a swap")
+	}
+	if p.CompareAndSwap(old, v) {
+		t.Fatal("CompareAndSwap reported swap when the old value did not match")
+	}
+
+	swapped, err := p.CompareAndSwapFields(
+		map[string]uint64{"index": 41},
+		map[string]uint64{"index": 1},
+	)
+	if swapped || err != nil {
+		t.Fatal("Unexpected swap:", swapped, err)
+	}
+	swapped, err = p.CompareAndSwapFields(
+		map[string]uint64{"index": 42},
+		map[string]uint64{"index": 1, "version": 1},
+	)
+	if !swapped || err != nil {
+		t.Fatal("Expected swap:", swapped, err)
+	}
+	if v := p.Load(); v.Get("index") != 1 || v.Get("version") != 1 {
+		t.Fatal("Unexpected fields:", v.Get("index"), v.Get("version"))
+	}
+
+	if _, err := p.CompareAndSwapFields(map[string]uint64{"missing": 0}, nil); !errors.Is(err, ErrUnknownField) {
+		t.Fatal("Expected ErrUnknownField, got", err)
+	}
+	if _, err := p.CompareAndSwapFields(nil, map[string]uint64{"version": 1 << 16}); !errors.Is(err, ErrFieldOverflow) {
+		t.Fatal("Expected ErrFieldOverflow, got", err)
+	}
+
+	errTest := errors.New("test")
+	if _, err := p.UpdateFields(func(v *View) error { return errTest }); err != errTest {
+		t.Fatal("Expected update error, got", err)
+	}
+	if v := p.Load(); v.Get("index") != 1 {
+		t.Fatal("Word changed by failed update")
+	}
+}
+
+func TestPacked64Concurrent(t *testing.T) {
+	l, _ := NewLayout(Field{"a", 0, 16}, Field{"b", 16, 16})
+	p := NewPacked64(l)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 500; j++ {
+				_, err := p.UpdateFields(func(v *View) error {
+					if err := v.Set("a", v.Get("a")+1); err != nil {
+						return err
+					}
+					return v.Set("b", v.Get("b")+2)
+				})
+				if err != nil {
+					t.Error("Unexpected error:", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if v := p.Load(); v.Get("a") != 4000 || v.Get("b") != 8000 {
+		t.Fatal("Lost updates:", v.Get("a"), v.Get("b"))
+	}
+}
@@ -0,0 +1,64 @@
+package atom
+
+// Pair32 is a pair of uint32 values stored in a single Uint64, so that both
+// values can be read and updated together atomically, e.g. a generation and a
+// count or the head and tail of a ring buffer.
+//
+// Signed values can be stored by converting them to uint32 and back.
+type Pair32 struct {
+	_    noCopy
+	word Uint64
+}
+
+func packPair32(a, b uint32) uint64 {
+	return uint64(a)<<32 | uint64(b)
+}
+
+func unpackPair32(word uint64) (a, b uint32) {
+	return uint32(word >> 32), uint32(word)
+}
+
+// AddA atomically adds delta to the first value and returns the new pair.
+// An overflow wraps around within the first value and does not affect the
+// second value. To subtract, add ^uint32(n-1).
+func (p *Pair32) AddA(delta uint32) (a, b uint32) {
+	for {
+		old := p.word.Value()
+		a, b = unpackPair32(old)
+		a += delta
+		if p.word.CompareAndSwap(old, packPair32(a, b)) {
+			return a, b
+		}
+	}
+}
+
+// AddB atomically adds delta to the second value and returns the new pair.
+// An overflow wraps around within the second value and does not carry over
+// into the first value. To subtract, add ^uint32(n-1).
+func (p *Pair32) AddB(delta uint32) (a, b uint32) {
+	for {
+		old := p.word.Value()
+		a, b = unpackPair32(old)
+		b += delta
+		if p.word.CompareAndSwap(old, packPair32(a, b)) {
+			return a, b
+		}
+	}
+}
+
+// CompareAndSwap atomically sets the new pair only if the current pair matches
+// the given old pair and returns whether the new pair was set.
+func (p *Pair32) CompareAndSwap(oldA, oldB, newA, newB uint32) (swapped bool) {
+	return p.word.CompareAndSwap(packPair32(oldA, oldB), packPair32(newA, newB))
+}
+
+// Load atomically returns both values of the pair.
+func (p *Pair32) Load() (a, b uint32) {
+	return unpackPair32(p.word.Value())
+}
+
+// Store atomically sets both values of the pair regardless of the previous
+// values.
+func (p *Pair32) Store(a, b uint32) {
+	p.word.Set(packPair32(a, b))
+}
@@ -0,0 +1,76 @@
+package atom
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestPair32(t *testing.T) {
+	var p Pair32
+	if a, b := p.Load(); a != 0 || b != 0 {
+		t.Fatal("Expected zero pair, got", a, b)
+	}
+
+	p.Store(1, 2)
+	if a, b := p.Load(); a != 1 || b != 2 {
+		t.Fatal("Expected (1, 2), got", a, b)
+	}
+
+	if p.CompareAndSwap(2, 1, 3, 4) {
+		t.Fatal("CompareAndSwap reported swap when the old pair did not match")
+	}
+	if !p.CompareAndSwap(1, 2, 3, 4) {
+		t.Fatal("CompareAndSwap did not report a swap")
+	}
+	if a, b := p.Load(); a != 3 || b != 4 {
+		t.Fatal("Expected (3, 4), got", a, b)
+	}
+
+	// overflows must not bleed into the other half
+	p.Store(1, 1<<32-1)
+	if a, b := p.AddB(1); a != 1 || b != 0 {
+		t.Fatal("Expected (1, 0), got", a, b)
+	}
+	if a, b := p.AddB(^uint32(0)); a != 1 || b != 1<<32-1 {
+		t.Fatal("Expected (1, max), got", a, b)
+	}
+	p.Store(1<<32-1, 5)
+	if a, b := p.AddA(1); a != 0 || b != 5 {
+		t.Fatal("Expected (0, 5), got", a, b)
+	}
+	if a, b := p.AddA(^uint32(1)); a != 1<<32-2 || b != 5 {
+		t.Fatal("Expected (max-1, 5), got", a, b)
+	}
+
+	// signed values
+	p.Store(uint32(-3&0xffffffff), 0)
+	if a, _ := p.AddA(1); int32(a) != -2 {
+		t.Fatal("Expected -2, got", int32(a))
+	}
+}
+
+func TestPair32Concurrent(t *testing.T) {
+	var p Pair32
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				p.AddA(1)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				p.AddB(^uint32(0))
+			}
+		}()
+	}
+	wg.Wait()
+
+	if a, b := p.Load(); a != 8000 || b != -8000&0xffffffff {
+		t.Fatal("Lost updates:", a, b)
+	}
+}
@@ -0,0 +1,96 @@
+//go:build purego || appengine || js
+// +build purego appengine js
+
+package atom
+
+import (
+	"math"
+	"reflect"
+	"sync/atomic"
+)
+
+// addrOf returns the address of p.
+func addrOf[T any](p *T) uintptr {
+	return reflect.ValueOf(p).Pointer()
+}
+
+// sliceIdentical reports whether the elements of a and b are identical.
+// Unlike reflect.DeepEqual, it treats func values and NaNs which were copied
+// as equal, and it does not follow pointers.
+// Without unsafe, func values are compared by their code pointer only.
+func sliceIdentical[T any](a, b []T) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !valueIdentical(reflect.ValueOf(&a[i]).Elem(), reflect.ValueOf(&b[i]).Elem()) {
+			return false
+		}
+	}
+	return true
+}
+
+func valueIdentical(a, b reflect.Value) bool {
+	switch a.Kind() {
+	case reflect.Bool:
+		return a.Bool() == b.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return a.Int() == b.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return a.Uint() == b.Uint()
+	case reflect.Float32, reflect.Float64:
+		return math.Float64bits(a.Float()) == math.Float64bits(b.Float())
+	case reflect.Complex64, reflect.Complex128:
+		ca, cb := a.Complex(), b.Complex()
+		return math.Float64bits(real(ca)) == math.Float64bits(real(cb)) &&
+			math.Float64bits(imag(ca)) == math.Float64bits(imag(cb))
+	case reflect.String:
+		return a.String() == b.String()
+	case reflect.Chan, reflect.Func, reflect.Map, reflect.Ptr, reflect.UnsafePointer:
+		return a.Pointer() == b.Pointer()
+	case reflect.Slice:
+		return a.Pointer() == b.Pointer() && a.Len() == b.Len() && a.Cap() == b.Cap()
+	case reflect.Interface:
+		if a.IsNil() || b.IsNil() {
+			return a.IsNil() == b.IsNil()
+		}
+		return a.Elem().Type() == b.Elem().Type() && valueIdentical(a.Elem(), b.Elem())
+	case reflect.Array:
+		for i := 0; i < a.Len(); i++ {
+			if !valueIdentical(a.Index(i), b.Index(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Struct:
+		for i := 0; i < a.NumField(); i++ {
+			if !valueIdentical(a.Field(i), b.Field(i)) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// seqValue stores the value of a SeqLock. Without unsafe, the value can not be
+// copied word by word, thus every store publishes a new copy of it.
+type seqValue[T any] struct {
+	value atomic.Pointer[T]
+}
+
+// load copies the stored value to value.
+func (s *seqValue[T]) load(value *T) {
+	if p := s.value.Load(); p != nil {
+		*value = *p
+		return
+	}
+	var zero T
+	*value = zero
+}
+
+// store copies value to the stored value. Stores must be serialized.
+func (s *seqValue[T]) store(value *T) {
+	v := *value
+	s.value.Store(&v)
+}
@@ -0,0 +1,136 @@
+package atom
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// RefCount is a reference counter, e.g. of a shared buffer, which calls a
+// finalizer exactly once when the last reference is released.
+//
+// Once the count dropped to zero, the counter is released for good: Acquire
+// panics and TryAcquire fails. Releasing a released counter is a no-op,
+// unless in debug mode.
+//
+// Unlike most types of this package, the zero value is not usable: a RefCount
+// must be created with NewRefCount, which also takes the first reference.
+type RefCount struct {
+	_ noCopy
+
+	// Debug enables the recording of the call sites of Acquire, TryAcquire
+	// and Release, and makes a Release of a released counter panic with the
+	// recorded call sites. It must be set before the first use.
+	Debug bool
+
+	count     Int64
+	finalizer func()
+	created   bool // set by NewRefCount to detect use of the zero value
+
+	mu    sync.Mutex
+	sites []string // call sites recorded in debug mode
+}
+
+// NewRefCount returns a new RefCount holding one reference, which calls the
+// finalizer when the count drops to zero. The finalizer is optional.
+func NewRefCount(finalizer func()) *RefCount {
+	r := &RefCount{finalizer: finalizer, created: true}
+	r.count.Set(1)
+	return r
+}
+
+// Acquire acquires a new reference.
+// It panics if the counter was already released or was not created with
+// NewRefCount.
+func (r *RefCount) Acquire() {
+	if !r.tryAcquire("Acquire") {
+		panic("atom: Acquire of released RefCount")
+	}
+}
+
+// CallSites returns the call sites of all acquires and releases recorded in
+// debug mode, e.g. to find leaked references.
+func (r *RefCount) CallSites() (sites []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append(sites, r.sites...)
+}
+
+// Count returns the current number of references.
+func (r *RefCount) Count() (n int64) {
+	return r.count.Value()
+}
+
+// Release releases a reference and calls the finalizer if it was the last
+// one. It reports whether the counter was released to zero by this call.
+// It panics if the counter was not created with NewRefCount.
+func (r *RefCount) Release() (released bool) {
+	for {
+		n := r.count.Value()
+		if n == 0 {
+			r.checkCreated("Release")
+			if r.Debug {
+				r.record("release", 2)
+				panic(fmt.Sprintf("atom: Release of released RefCount, call sites:\n%s",
+					strings.Join(r.CallSites(), "\n")))
+			}
+			return false
+		}
+		if r.count.CompareAndSwap(n, n-1) {
+			if r.Debug {
+				r.record("release", 2)
+			}
+			if n > 1 {
+				return false
+			}
+			if r.finalizer != nil {
+				r.finalizer()
+			}
+			return true
+		}
+	}
+}
+
+// TryAcquire acquires a new reference unless the counter was already released
+// and reports whether it succeeded.
+// It panics if the counter was not created with NewRefCount.
+func (r *RefCount) TryAcquire() (acquired bool) {
+	return r.tryAcquire("TryAcquire")
+}
+
+func (r *RefCount) tryAcquire(op string) (acquired bool) {
+	for {
+		n := r.count.Value()
+		if n == 0 {
+			r.checkCreated(op)
+			return false
+		}
+		if r.count.CompareAndSwap(n, n+1) {
+			if r.Debug {
+				// skip tryAcquire and the exported method
+				r.record("acquire", 3)
+			}
+			return true
+		}
+	}
+}
+
+// checkCreated panics if the counter is a zero value, which would otherwise
+// be indistinguishable from a released one.
+func (r *RefCount) checkCreated(op string) {
+	if !r.created {
+		panic("atom: " + op + " of RefCount not created with NewRefCount")
+	}
+}
+
+// record records the call site skip frames above record.
+func (r *RefCount) record(op string, skip int) {
+	site := op + " at unknown location"
+	if _, file, line, ok := runtime.Caller(skip); ok {
+		site = fmt.Sprintf("%s at %s:%d", op, file, line)
+	}
+	r.mu.Lock()
+	r.sites = append(r.sites, site)
+	r.mu.Unlock()
+}
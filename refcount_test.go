@@ -0,0 +1,141 @@
+package atom
+
+import (
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestRefCount(t *testing.T) {
+	var finalized Int32
+	r := NewRefCount(func() { finalized.Add(1) })
+	if n := r.Count(); n != 1 {
+		t.Fatal("Expected count 1, got", n)
+	}
+
+	r.Acquire()
+	if !r.TryAcquire() {
+		t.Fatal("TryAcquire failed")
+	}
+	if n := r.Count(); n != 3 {
+		t.Fatal("Expected count 3, got", n)
+	}
+
+	if r.Release() || r.Release() {
+		t.Fatal("Release reported release to zero too early")
+	}
+	if finalized.Value() != 0 {
+		t.Fatal("Finalizer called too early")
+	}
+	if !r.Release() {
+		t.Fatal("Release did not report release to zero")
+	}
+	if finalized.Value() != 1 {
+		t.Fatal("Finalizer not called")
+	}
+
+	// the counter stays released
+	if r.TryAcquire() {
+		t.Fatal("TryAcquire of released counter succeeded")
+	}
+	if r.Release() {
+		t.Fatal("Release of released counter reported release to zero")
+	}
+	if finalized.Value() != 1 {
+		t.Fatal("Finalizer called more than once")
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("Expected panic for Acquire of released counter")
+			}
+		}()
+		r.Acquire()
+	}()
+
+	// the finalizer is optional
+	if !NewRefCount(nil).Release() {
+		t.Fatal("Release did not report release to zero")
+	}
+}
+
+func TestRefCountDebug(t *testing.T) {
+	r := NewRefCount(nil)
+	r.Debug = true
+	r.Acquire()
+	r.TryAcquire()
+	r.Release()
+	r.Release()
+	r.Release()
+
+	sites := r.CallSites()
+	if len(sites) != 5 {
+		t.Fatal("Expected 5 call sites, got", sites)
+	}
+	for i, op := range []string{"acquire", "acquire", "release", "release", "release"} {
+		if !strings.HasPrefix(sites[i], op+" at ") || !strings.Contains(sites[i], "refcount_test.go:") {
+			t.Fatal("Unexpected call site", sites[i])
+		}
+	}
+
+	defer func() {
+		msg, _ := recover().(string)
+		if !strings.Contains(msg, "Release of released RefCount") || strings.Count(msg, "refcount_test.go:") != 6 {
+			t.Fatal("Unexpected panic:", msg)
+		}
+	}()
+	r.Release()
+}
+
+func TestRefCountZeroValuePanic(t *testing.T) {
+	for name, f := range map[string]func(r *RefCount){
+		"Acquire":    func(r *RefCount) { r.Acquire() },
+		"TryAcquire": func(r *RefCount) { r.TryAcquire() },
+		"Release":    func(r *RefCount) { r.Release() },
+	} {
+		func() {
+			defer func() {
+				msg, _ := recover().(string)
+				if msg != "atom: "+name+" of RefCount not created with NewRefCount" {
+					t.Fatal("Unexpected panic:", msg)
+				}
+			}()
+			var r RefCount
+			f(&r)
+		}()
+	}
+
+	// a released counter must not be mistaken for a zero value
+	r := NewRefCount(nil)
+	r.Release()
+	if r.TryAcquire() || r.Release() {
+		t.Fatal("Released RefCount was acquired or released")
+	}
+}
+
+func TestRefCountConcurrent(t *testing.T) {
+	var finalized Int32
+	r := NewRefCount(func() { finalized.Add(1) })
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				if r.TryAcquire() {
+					r.Release()
+				}
+			}
+		}()
+	}
+	r.Release()
+	wg.Wait()
+
+	if n := finalized.Value(); n != 1 {
+		t.Fatal("Expected the finalizer to be called once, got", n)
+	}
+	if n := r.Count(); n != 0 {
+		t.Fatal("Expected count 0, got", n)
+	}
+}
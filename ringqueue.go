@@ -0,0 +1,168 @@
+package atom
+
+import (
+	"context"
+)
+
+// cacheLinePad prevents false sharing between the fields it separates.
+type cacheLinePad [64]byte
+
+// RingQueue is a bounded lock-free multi-producer multi-consumer FIFO queue
+// based on Dmitry Vyukov's bounded MPMC queue.
+//
+// Every slot of the ring buffer carries a sequence number, which tells
+// producers and consumers whether the slot is ready to be written or read.
+// Producers and consumers thus only contend on their respective position
+// counters, which are padded to reside on separate cache lines.
+type RingQueue[T any] struct {
+	_       noCopy
+	_       cacheLinePad
+	enqPos  Uint64
+	_       cacheLinePad
+	deqPos  Uint64
+	_       cacheLinePad
+	mask    uint64
+	slots   []ringSlot[T]
+	waiters Int32 // number of goroutines blocked in Enqueue or Dequeue
+}
+
+type ringSlot[T any] struct {
+	seq   Uint64
+	value T
+}
+
+// NewRingQueue returns a new RingQueue with the given capacity.
+// It panics if the capacity is not a power of two of at least 2, as a single
+// slot could not distinguish between a written and a read slot.
+func NewRingQueue[T any](capacity int) *RingQueue[T] {
+	if capacity < 2 || capacity&(capacity-1) != 0 {
+		panic("atom: RingQueue capacity must be a power of two >= 2")
+	}
+	q := &RingQueue[T]{
+		mask:  uint64(capacity - 1),
+		slots: make([]ringSlot[T], capacity),
+	}
+	for i := range q.slots {
+		q.slots[i].seq.Set(uint64(i))
+	}
+	return q
+}
+
+// Cap returns the capacity of the queue.
+func (q *RingQueue[T]) Cap() (capacity int) {
+	return len(q.slots)
+}
+
+// Dequeue removes and returns the first element, blocking until an element
+// is available or the context is done. It returns the context's error in the
+// latter case.
+func (q *RingQueue[T]) Dequeue(ctx context.Context) (value T, err error) {
+	if value, ok := q.TryDequeue(); ok {
+		return value, nil
+	}
+	err = q.wait(ctx, func() (ok bool) {
+		value, ok = q.TryDequeue()
+		return ok
+	})
+	return value, err
+}
+
+// Enqueue appends the value to the end of the queue, blocking until a slot
+// is available or the context is done. It returns the context's error in the
+// latter case.
+func (q *RingQueue[T]) Enqueue(ctx context.Context, value T) error {
+	if q.TryEnqueue(value) {
+		return nil
+	}
+	return q.wait(ctx, func() bool {
+		return q.TryEnqueue(value)
+	})
+}
+
+// Len returns the number of elements in the queue. It is only a snapshot and
+// may be outdated by the time it is returned.
+func (q *RingQueue[T]) Len() (n int) {
+	enq := q.enqPos.Value()
+	deq := q.deqPos.Value()
+	switch {
+	case enq <= deq:
+		return 0
+	case enq-deq > q.mask:
+		return len(q.slots)
+	}
+	return int(enq - deq)
+}
+
+// TryDequeue removes and returns the first element without blocking.
+// The ok result is false if the queue is empty.
+func (q *RingQueue[T]) TryDequeue() (value T, ok bool) {
+	pos := q.deqPos.Value()
+	for {
+		slot := &q.slots[pos&q.mask]
+		seq := slot.seq.Value()
+		switch diff := int64(seq - (pos + 1)); {
+		case diff == 0:
+			if !q.deqPos.CompareAndSwap(pos, pos+1) {
+				pos = q.deqPos.Value()
+				continue
+			}
+			value = slot.value
+			var zero T
+			slot.value = zero // allow the value to be garbage collected
+			// mark the slot as ready to be written in the next round
+			slot.seq.Set(pos + q.mask + 1)
+			q.wakeup()
+			return value, true
+		case diff < 0:
+			// the slot was not written yet
+			return value, false
+		default:
+			// another consumer was faster
+			pos = q.deqPos.Value()
+		}
+	}
+}
+
+// TryEnqueue appends the value to the end of the queue without blocking and
+// reports whether it succeeded. It fails if the queue is full.
+func (q *RingQueue[T]) TryEnqueue(value T) (ok bool) {
+	pos := q.enqPos.Value()
+	for {
+		slot := &q.slots[pos&q.mask]
+		seq := slot.seq.Value()
+		switch diff := int64(seq - pos); {
+		case diff == 0:
+			if !q.enqPos.CompareAndSwap(pos, pos+1) {
+				pos = q.enqPos.Value()
+				continue
+			}
+			slot.value = value
+			// mark the slot as ready to be read
+			slot.seq.Set(pos + 1)
+			q.wakeup()
+			return true
+		case diff < 0:
+			// the slot was not read yet in the previous round
+			return false
+		default:
+			// another producer was faster
+			pos = q.enqPos.Value()
+		}
+	}
+}
+
+// wait parks until try succeeds or the context is done.
+func (q *RingQueue[T]) wait(ctx context.Context, try func() bool) error {
+	// The waiter must be visible before try is called again by park,
+	// otherwise the wakeup by a concurrent operation might get lost.
+	q.waiters.Add(1)
+	defer q.waiters.Sub(1)
+	return park(ctx, q, try)
+}
+
+// wakeup wakes up blocked producers and consumers, if there are any.
+func (q *RingQueue[T]) wakeup() {
+	if q.waiters.Value() != 0 {
+		notify(q)
+	}
+}
@@ -0,0 +1,160 @@
+package atom
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRingQueue(t *testing.T) {
+	q := NewRingQueue[int](4)
+	if c := q.Cap(); c != 4 {
+		t.Fatal("Expected capacity 4, got", c)
+	}
+	if _, ok := q.TryDequeue(); ok {
+		t.Fatal("TryDequeue succeeded on empty queue")
+	}
+
+	// multiple rounds through the ring buffer
+	for round := 0; round < 3; round++ {
+		for i := 0; i < 4; i++ {
+			if !q.TryEnqueue(round*4 + i) {
+				t.Fatal("TryEnqueue failed on non-full queue")
+			}
+		}
+		if q.TryEnqueue(-1) {
+			t.Fatal("TryEnqueue succeeded on full queue")
+		}
+		if n := q.Len(); n != 4 {
+			t.Fatal("Expected length 4, got", n)
+		}
+		for i := 0; i < 4; i++ {
+			if v, ok := q.TryDequeue(); !ok || v != round*4+i {
+				t.Fatalf("Expected %d, got %d %v", round*4+i, v, ok)
+			}
+		}
+		if n := q.Len(); n != 0 {
+			t.Fatal("Expected length 0, got", n)
+		}
+	}
+}
+
+func TestRingQueueBlocking(t *testing.T) {
+	q := NewRingQueue[string](2)
+
+	for _, v := range []string{"a", "b"} {
+		if err := q.Enqueue(context.Background(), v); err != nil {
+			t.Fatal("Unexpected error:", err)
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	if err := q.Enqueue(ctx, "c"); err != context.DeadlineExceeded {
+		t.Fatal("Expected context.DeadlineExceeded, got", err)
+	}
+
+	done := make(chan error)
+	go func() {
+		done <- q.Enqueue(context.Background(), "c")
+	}()
+	for q.waiters.Value() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	if v, err := q.Dequeue(context.Background()); err != nil || v != "a" {
+		t.Fatal("Unexpected result:", v, err)
+	}
+	if err := <-done; err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	for _, expected := range []string{"b", "c"} {
+		if v, err := q.Dequeue(context.Background()); err != nil || v != expected {
+			t.Fatal("Unexpected result:", v, err)
+		}
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	if _, err := q.Dequeue(ctx); err != context.DeadlineExceeded {
+		t.Fatal("Expected context.DeadlineExceeded, got", err)
+	}
+}
+
+func TestNewRingQueuePanic(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("Expected panic for capacity which is not a power of two")
+		}
+	}()
+	NewRingQueue[int](3)
+}
+
+func TestNewRingQueuePanicSingleSlot(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("Expected panic for a single slot")
+		}
+	}()
+	NewRingQueue[int](1)
+}
+
+func TestRingQueueConcurrent(t *testing.T) {
+	const (
+		workers   = 4
+		perWorker = 1000
+	)
+	q := NewRingQueue[int](8)
+
+	var dequeued [workers * perWorker]Uint32
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < perWorker; j++ {
+				if err := q.Enqueue(context.Background(), i*perWorker+j); err != nil {
+					t.Error("Unexpected error:", err)
+					return
+				}
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < perWorker; j++ {
+				v, err := q.Dequeue(context.Background())
+				if err != nil {
+					t.Error("Unexpected error:", err)
+					return
+				}
+				dequeued[v].Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	for v := range dequeued {
+		if n := dequeued[v].Value(); n != 1 {
+			t.Fatalf("Expected %d to be dequeued once, dequeued %d times", v, n)
+		}
+	}
+}
+
+func BenchmarkRingQueue(b *testing.B) {
+	q := NewRingQueue[int](1024)
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			q.Enqueue(context.Background(), 1)
+			q.Dequeue(context.Background())
+		}
+	})
+}
+
+func BenchmarkRingChannel(b *testing.B) {
+	ch := make(chan int, 1024)
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			ch <- 1
+			<-ch
+		}
+	})
+}
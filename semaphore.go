@@ -0,0 +1,168 @@
+package atom
+
+import (
+	"container/list"
+	"context"
+	"sync"
+)
+
+// Semaphore is a weighted semaphore with an adjustable capacity.
+//
+// While no goroutine is blocked, acquiring and releasing is a single
+// CompareAndSwap on an atomic counter. Blocked goroutines are queued and
+// served in FIFO order, i.e. a large request at the front of the queue blocks
+// smaller requests behind it.
+type Semaphore struct {
+	_        noCopy
+	capacity Int64
+	used     Int64
+	waiting  Int32
+
+	mu      sync.Mutex // guards waiters
+	waiters list.List
+}
+
+type semaphoreWaiter struct {
+	n     int64
+	ready chan struct{} // closed when the units were acquired
+}
+
+// NewSemaphore returns a new Semaphore with the given capacity.
+func NewSemaphore(capacity int64) *Semaphore {
+	s := &Semaphore{}
+	s.capacity.Set(capacity)
+	return s
+}
+
+// Acquire acquires n units, blocking until they are available or the context
+// is done. On failure it returns the context's error and leaves the semaphore
+// unchanged.
+// A request for more units than the capacity blocks until the capacity is
+// increased sufficiently.
+// It panics if n is not positive.
+func (s *Semaphore) Acquire(ctx context.Context, n int64) error {
+	if s.TryAcquire(n) {
+		return nil
+	}
+
+	s.mu.Lock()
+	w := &semaphoreWaiter{n: n, ready: make(chan struct{})}
+	elem := s.waiters.PushBack(w)
+	// The waiting count must be visible before the semaphore is re-checked,
+	// otherwise a concurrent Release might miss the waiter.
+	s.waiting.Add(1)
+	s.grant()
+	s.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return nil
+
+	case <-ctx.Done():
+		s.mu.Lock()
+		select {
+		case <-w.ready:
+			// Acquired after the context was done. Pretend it was not and
+			// put the units back.
+			s.mu.Unlock()
+			s.Release(n)
+		default:
+			front := s.waiters.Front() == elem
+			s.waiters.Remove(elem)
+			s.waiting.Sub(1)
+			if front {
+				// the next waiter might fit now
+				s.grant()
+			}
+			s.mu.Unlock()
+		}
+		return ctx.Err()
+	}
+}
+
+// Capacity returns the current capacity.
+func (s *Semaphore) Capacity() (capacity int64) {
+	return s.capacity.Value()
+}
+
+// Release releases n units.
+// It panics if n is not positive or more units are released than are
+// currently held. The semaphore is left unchanged in that case.
+func (s *Semaphore) Release(n int64) {
+	checkSemaphoreUnits(n)
+	for {
+		used := s.used.Value()
+		if n > used {
+			panic("atom: semaphore released more than held")
+		}
+		if s.used.CompareAndSwap(used, used-n) {
+			break
+		}
+	}
+	if s.waiting.Value() != 0 {
+		s.mu.Lock()
+		s.grant()
+		s.mu.Unlock()
+	}
+}
+
+// SetCapacity sets the capacity. Units that are currently held are not
+// affected if the capacity is decreased below the number of held units,
+// further acquisitions simply block until enough units were released.
+func (s *Semaphore) SetCapacity(capacity int64) {
+	s.capacity.Set(capacity)
+	if s.waiting.Value() != 0 {
+		s.mu.Lock()
+		s.grant()
+		s.mu.Unlock()
+	}
+}
+
+// TryAcquire acquires n units without blocking and reports whether it
+// succeeded. It fails if other goroutines are blocked in Acquire.
+// It panics if n is not positive.
+func (s *Semaphore) TryAcquire(n int64) (acquired bool) {
+	checkSemaphoreUnits(n)
+	for {
+		used := s.used.Value()
+		if s.waiting.Value() != 0 || used+n > s.capacity.Value() {
+			return false
+		}
+		if s.used.CompareAndSwap(used, used+n) {
+			return true
+		}
+	}
+}
+
+// Used returns the number of currently held units.
+func (s *Semaphore) Used() (used int64) {
+	return s.used.Value()
+}
+
+func checkSemaphoreUnits(n int64) {
+	if n <= 0 {
+		panic("atom: non-positive number of semaphore units")
+	}
+}
+
+// grant hands out units to the queued waiters in FIFO order as long as they
+// fit into the capacity. s.mu must be held.
+func (s *Semaphore) grant() {
+	for {
+		elem := s.waiters.Front()
+		if elem == nil {
+			return
+		}
+		w := elem.Value.(*semaphoreWaiter)
+		used := s.used.Value()
+		if used+w.n > s.capacity.Value() {
+			return
+		}
+		if !s.used.CompareAndSwap(used, used+w.n) {
+			continue
+		}
+		s.waiters.Remove(elem)
+		s.waiting.Sub(1)
+		close(w.ready)
+	}
+}
@@ -0,0 +1,215 @@
+package atom
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSemaphore(t *testing.T) {
+	s := NewSemaphore(10)
+	if c := s.Capacity(); c != 10 {
+		t.Fatal("Expected capacity 10, got", c)
+	}
+
+	if !s.TryAcquire(4) {
+		t.Fatal("TryAcquire failed")
+	}
+	if err := s.Acquire(context.Background(), 6); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if u := s.Used(); u != 10 {
+		t.Fatal("Expected 10 used units, got", u)
+	}
+	if s.TryAcquire(1) {
+		t.Fatal("TryAcquire succeeded beyond the capacity")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	if err := s.Acquire(ctx, 1); err != context.DeadlineExceeded {
+		t.Fatal("Expected context.DeadlineExceeded, got", err)
+	}
+	if u := s.Used(); u != 10 {
+		t.Fatal("Expected 10 used units, got", u)
+	}
+
+	s.Release(10)
+	if u := s.Used(); u != 0 {
+		t.Fatal("Expected 0 used units, got", u)
+	}
+}
+
+func TestSemaphoreFIFO(t *testing.T) {
+	s := NewSemaphore(2)
+	if !s.TryAcquire(2) {
+		t.Fatal("TryAcquire failed")
+	}
+
+	order := make(chan int64, 3)
+	acquire := func(n int64) {
+		if err := s.Acquire(context.Background(), n); err != nil {
+			t.Error("Unexpected error:", err)
+		}
+		order <- n
+	}
+
+	// a large request at the front blocks the smaller ones behind it
+	go acquire(2)
+	for s.waiting.Value() < 1 {
+		time.Sleep(time.Millisecond)
+	}
+	go acquire(1)
+	for s.waiting.Value() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	if s.TryAcquire(1) {
+		t.Fatal("TryAcquire bypassed the queue")
+	}
+
+	s.Release(1)
+	select {
+	case n := <-order:
+		t.Fatal("Unexpected acquisition of", n)
+	case <-time.After(5 * time.Millisecond):
+	}
+
+	s.Release(1)
+	if n := <-order; n != 2 {
+		t.Fatal("Expected the request for 2 units first, got", n)
+	}
+	s.Release(2)
+	if n := <-order; n != 1 {
+		t.Fatal("Expected the request for 1 unit second, got", n)
+	}
+	s.Release(1)
+}
+
+func TestSemaphoreCancelFront(t *testing.T) {
+	s := NewSemaphore(2)
+	if !s.TryAcquire(1) {
+		t.Fatal("TryAcquire failed")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	front := make(chan error)
+	go func() {
+		front <- s.Acquire(ctx, 2)
+	}()
+	for s.waiting.Value() < 1 {
+		time.Sleep(time.Millisecond)
+	}
+	behind := make(chan error)
+	go func() {
+		behind <- s.Acquire(context.Background(), 1)
+	}()
+	for s.waiting.Value() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// cancelling the blocking front waiter lets the one behind it pass
+	cancel()
+	if err := <-front; err != context.Canceled {
+		t.Fatal("Expected context.Canceled, got", err)
+	}
+	if err := <-behind; err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if u := s.Used(); u != 2 {
+		t.Fatal("Expected 2 used units, got", u)
+	}
+}
+
+func TestSemaphoreSetCapacity(t *testing.T) {
+	s := NewSemaphore(1)
+
+	done := make(chan error)
+	go func() {
+		done <- s.Acquire(context.Background(), 3)
+	}()
+	for s.waiting.Value() < 1 {
+		time.Sleep(time.Millisecond)
+	}
+
+	s.SetCapacity(3)
+	if err := <-done; err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+
+	// decreasing the capacity does not affect held units
+	s.SetCapacity(1)
+	if u := s.Used(); u != 3 {
+		t.Fatal("Expected 3 used units, got", u)
+	}
+	s.Release(3)
+	if !s.TryAcquire(1) {
+		t.Fatal("TryAcquire failed")
+	}
+}
+
+func TestSemaphoreReleasePanic(t *testing.T) {
+	s := NewSemaphore(2)
+	s.TryAcquire(1)
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("Expected panic when releasing more than held")
+			}
+		}()
+		s.Release(2)
+	}()
+	if n := s.Used(); n != 1 {
+		t.Fatal("Expected 1 used unit after failed release, got", n)
+	}
+}
+
+func TestSemaphoreNonPositivePanic(t *testing.T) {
+	s := NewSemaphore(2)
+	tests := map[string]func(){
+		"Acquire":    func() { s.Acquire(context.Background(), 0) },
+		"TryAcquire": func() { s.TryAcquire(-1) },
+		"Release":    func() { s.Release(-5) },
+	}
+	for name, f := range tests {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: Expected panic for non-positive units", name)
+				}
+			}()
+			f()
+		}()
+	}
+	if n := s.Used(); n != 0 {
+		t.Fatal("Expected no used units, got", n)
+	}
+}
+
+func TestSemaphoreConcurrent(t *testing.T) {
+	const capacity = 5
+	s := NewSemaphore(capacity)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(n int64) {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				if err := s.Acquire(context.Background(), n); err != nil {
+					t.Error("Unexpected error:", err)
+					return
+				}
+				if u := s.Used(); u > capacity {
+					t.Error("Capacity exceeded:", u)
+				}
+				s.Release(n)
+			}
+		}(int64(i%3 + 1))
+	}
+	wg.Wait()
+
+	if u := s.Used(); u != 0 {
+		t.Fatal("Expected 0 used units, got", u)
+	}
+}
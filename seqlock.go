@@ -0,0 +1,99 @@
+package atom
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// SeqLock is a sequence lock protecting a value of type T, typically a small
+// struct of multiple fields which must be read consistently.
+//
+// Readers never block the writer and do not allocate. They merely retry if
+// the value was modified while they were copying it. Writers are serialized.
+//
+// The value is stored in words which are only accessed atomically, thus
+// readers copying it concurrently to a write do not race with the writer.
+// As the value is copied word by word, T must not contain pointers, strings,
+// slices, maps, channels, funcs or interfaces; Write panics otherwise.
+// Without unsafe, every write publishes a new copy of the value instead, which
+// allocates.
+type SeqLock[T any] struct {
+	_     noCopy
+	seq   Uint64 // odd while a write is in progress
+	value seqValue[T]
+
+	// owned by the writer holding the lock
+	shadow  T
+	checked bool
+}
+
+// Read returns a consistent copy of the value.
+func (l *SeqLock[T]) Read() (value T) {
+	var b spinBackoff
+	for {
+		seq := l.seq.Value()
+		if seq&1 == 0 {
+			l.value.load(&value)
+			if l.seq.Value() == seq {
+				return value
+			}
+		}
+		b.wait()
+	}
+}
+
+// Sequence returns the current sequence number. It is incremented twice
+// by every write and is odd while a write is in progress.
+func (l *SeqLock[T]) Sequence() (seq uint64) {
+	return l.seq.Value()
+}
+
+// Write calls update with a pointer to a copy of the value, which may be
+// modified in place and is published when update returns. The pointer must
+// not be retained after update returns.
+// It panics if T contains pointers.
+func (l *SeqLock[T]) Write(update func(value *T)) {
+	var b spinBackoff
+	for {
+		seq := l.seq.Value()
+		if seq&1 == 0 && l.seq.CompareAndSwap(seq, seq+1) {
+			defer l.seq.Set(seq + 2)
+			if !l.checked {
+				checkSeqLockType(reflect.TypeOf(&l.shadow).Elem())
+				l.checked = true
+			}
+			defer l.value.store(&l.shadow)
+			update(&l.shadow)
+			return
+		}
+		b.wait()
+	}
+}
+
+// checkSeqLockType panics if values of type t can not be copied word by word.
+func checkSeqLockType(t reflect.Type) {
+	if hasPointers(t) {
+		panic(fmt.Sprintf("atom: SeqLock of type %s containing pointers", t))
+	}
+}
+
+// hasPointers reports whether values of type t contain pointers.
+func hasPointers(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128:
+		return false
+	case reflect.Array:
+		return t.Len() > 0 && hasPointers(t.Elem())
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if hasPointers(t.Field(i).Type) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
@@ -0,0 +1,125 @@
+package atom
+
+import (
+	"strings"
+	"sync"
+	"testing"
+)
+
+type seqStats struct {
+	min, max, sum int64
+}
+
+func TestSeqLock(t *testing.T) {
+	var l SeqLock[seqStats]
+	if v := l.Read(); v != (seqStats{}) {
+		t.Fatal("Expected initial value to be the zero value, got", v)
+	}
+	if seq := l.Sequence(); seq != 0 {
+		t.Fatal("Expected initial sequence 0, got", seq)
+	}
+
+	l.Write(func(s *seqStats) {
+		s.min, s.max, s.sum = 1, 3, 6
+	})
+	if v := l.Read(); v != (seqStats{1, 3, 6}) {
+		t.Fatal("Value unchanged:", v)
+	}
+	if seq := l.Sequence(); seq != 2 {
+		t.Fatal("Expected sequence 2, got", seq)
+	}
+
+	// a panicking update does not leave the lock in the write state
+	func() {
+		defer func() {
+			recover()
+		}()
+		l.Write(func(s *seqStats) {
+			panic("update")
+		})
+	}()
+	if seq := l.Sequence(); seq != 4 {
+		t.Fatal("Expected sequence 4, got", seq)
+	}
+}
+
+func TestSeqLockOddSize(t *testing.T) {
+	type odd struct {
+		a int16
+		b [9]byte
+	}
+	var l SeqLock[odd]
+	l.Write(func(v *odd) {
+		v.a = -2
+		v.b = [9]byte{1, 2, 3, 4, 5, 6, 7, 8, 9}
+	})
+	if v := l.Read(); v != (odd{-2, [9]byte{1, 2, 3, 4, 5, 6, 7, 8, 9}}) {
+		t.Fatal("Unexpected value:", v)
+	}
+
+	var empty SeqLock[struct{}]
+	empty.Write(func(*struct{}) {})
+	empty.Read()
+}
+
+func TestSeqLockPointerPanic(t *testing.T) {
+	defer func() {
+		msg, _ := recover().(string)
+		if !strings.Contains(msg, "containing pointers") {
+			t.Fatal("Unexpected panic:", msg)
+		}
+	}()
+	type named struct {
+		n    int
+		name string
+	}
+	var l SeqLock[named]
+	l.Write(func(*named) {})
+}
+
+func TestSeqLockConcurrent(t *testing.T) {
+	var l SeqLock[seqStats]
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := int64(1); j <= 1000; j++ {
+				l.Write(func(s *seqStats) {
+					s.min = -j
+					s.max = j
+					s.sum = s.min + s.max
+				})
+			}
+		}()
+	}
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				v := l.Read()
+				if v.min != -v.max || v.sum != 0 {
+					t.Error("Inconsistent read:", v)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if seq := l.Sequence(); seq != 4000 {
+		t.Fatal("Expected sequence 4000, got", seq)
+	}
+}
+
+func BenchmarkSeqLockRead(b *testing.B) {
+	var l SeqLock[seqStats]
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_ = l.Read()
+		}
+	})
+}
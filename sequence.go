@@ -0,0 +1,132 @@
+package atom
+
+import (
+	"errors"
+	"math"
+	"sync"
+)
+
+// ErrSequenceExhausted is returned when a Sequence has no values left.
+var ErrSequenceExhausted = errors.New("sequence exhausted")
+
+// Sequence is a generator of unique uint64 values, e.g. request IDs.
+//
+// The values of a sequence are Start, Start+Step, Start+2*Step and so on.
+// Next and NextN reserve values with a CompareAndSwap on a shared counter.
+// If BlockSize is set, Next instead hands out values from blocks of
+// consecutive values which are cached per P (see sync.Pool), so that
+// goroutines calling Next at a high rate mostly avoid touching the shared
+// counter. Values are then still unique, but no longer increasing across
+// goroutines, and values of cached blocks dropped by the garbage collector are
+// skipped.
+//
+// Start, Step, Limit, Wrap and BlockSize must be set before the first use.
+// The zero value is an unlimited sequence of 0, 1, 2 and so on.
+type Sequence struct {
+	_ noCopy
+
+	// Start is the first value of the sequence.
+	Start uint64
+
+	// Step is the difference between consecutive values.
+	// A value of 0 is treated as 1.
+	Step uint64
+
+	// Limit is the number of values of the sequence. A value of 0 means
+	// that the sequence only ends when the values would overflow.
+	// A sequence has at most 1<<64-1 values.
+	Limit uint64
+
+	// Wrap makes the sequence start over at Start once it is exhausted,
+	// instead of returning ErrSequenceExhausted. Values are then only unique
+	// within a round.
+	Wrap bool
+
+	// BlockSize is the number of values Next reserves at once for its cache.
+	// A value of 0 or 1 disables the cache.
+	BlockSize uint64
+
+	next  Uint64    // index of the next value to reserve
+	cache sync.Pool // holds *sequenceBlock values
+}
+
+type sequenceBlock struct {
+	next uint64 // next value to hand out
+	n    uint64 // number of values left
+}
+
+// Next returns the next value of the sequence.
+// It returns ErrSequenceExhausted if the sequence has no values left.
+func (s *Sequence) Next() (value uint64, err error) {
+	if s.BlockSize <= 1 {
+		return s.NextN(1)
+	}
+
+	b, _ := s.cache.Get().(*sequenceBlock)
+	if b == nil || b.n == 0 {
+		first, err := s.NextN(s.BlockSize)
+		if err != nil {
+			// a smaller block might still be available
+			return s.NextN(1)
+		}
+		b = &sequenceBlock{next: first, n: s.BlockSize}
+	}
+	value = b.next
+	b.next += s.step()
+	b.n--
+	s.cache.Put(b)
+	return value, nil
+}
+
+// NextN reserves n consecutive values of the sequence and returns the first
+// one. The reserved values are first, first+Step, ..., first+(n-1)*Step.
+// A reserved range never spans the start of a new round of a wrapping
+// sequence. It returns ErrSequenceExhausted if fewer than n values are left.
+// It panics if n is 0.
+func (s *Sequence) NextN(n uint64) (first uint64, err error) {
+	if n == 0 {
+		panic("atom: Sequence NextN of zero values")
+	}
+
+	last := s.last()
+	if n-1 > last {
+		return 0, ErrSequenceExhausted
+	}
+	for {
+		index := s.next.Value()
+		if index > last || n-1 > last-index {
+			if !s.Wrap {
+				return 0, ErrSequenceExhausted
+			}
+			// start over at the first index
+			if s.next.CompareAndSwap(index, n) {
+				return s.Start, nil
+			}
+			continue
+		}
+		if s.next.CompareAndSwap(index, index+n) {
+			return s.Start + index*s.step(), nil
+		}
+	}
+}
+
+// last returns the index of the last value of the sequence. It is below
+// MaxUint64, so that the index following it, which marks the sequence as
+// exhausted, can be stored without overflowing.
+func (s *Sequence) last() (index uint64) {
+	index = (math.MaxUint64 - s.Start) / s.step()
+	if index == math.MaxUint64 {
+		index--
+	}
+	if s.Limit > 0 && s.Limit-1 < index {
+		index = s.Limit - 1
+	}
+	return index
+}
+
+func (s *Sequence) step() (step uint64) {
+	if s.Step == 0 {
+		return 1
+	}
+	return s.Step
+}
@@ -0,0 +1,160 @@
+package atom
+
+import (
+	"math"
+	"sync"
+	"testing"
+)
+
+func TestSequence(t *testing.T) {
+	var s Sequence
+	for i := uint64(0); i < 3; i++ {
+		if v, err := s.Next(); v != i || err != nil {
+			t.Fatal("Unexpected result:", v, err)
+		}
+	}
+	if first, err := s.NextN(10); first != 3 || err != nil {
+		t.Fatal("Unexpected result:", first, err)
+	}
+	if v, err := s.Next(); v != 13 || err != nil {
+		t.Fatal("Unexpected result:", v, err)
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("Expected panic for NextN(0)")
+			}
+		}()
+		s.NextN(0)
+	}()
+}
+
+func TestSequenceLimit(t *testing.T) {
+	s := Sequence{Start: 100, Step: 10, Limit: 5}
+	if first, err := s.NextN(3); first != 100 || err != nil {
+		t.Fatal("Unexpected result:", first, err)
+	}
+	if _, err := s.NextN(3); err != ErrSequenceExhausted {
+		t.Fatal("Expected ErrSequenceExhausted, got", err)
+	}
+	if first, err := s.NextN(2); first != 130 || err != nil {
+		t.Fatal("Unexpected result:", first, err)
+	}
+	if _, err := s.Next(); err != ErrSequenceExhausted {
+		t.Fatal("Expected ErrSequenceExhausted, got", err)
+	}
+
+	// values must not overflow
+	s = Sequence{Start: math.MaxUint64 - 2, Step: 2}
+	if v, err := s.Next(); v != math.MaxUint64-2 || err != nil {
+		t.Fatal("Unexpected result:", v, err)
+	}
+	if v, err := s.Next(); v != math.MaxUint64 || err != nil {
+		t.Fatal("Unexpected result:", v, err)
+	}
+	if _, err := s.Next(); err != ErrSequenceExhausted {
+		t.Fatal("Expected ErrSequenceExhausted, got", err)
+	}
+
+	// the index after the last value must not overflow
+	s = Sequence{}
+	if first, err := s.NextN(math.MaxUint64); first != 0 || err != nil {
+		t.Fatal("Unexpected result:", first, err)
+	}
+	for i := 0; i < 2; i++ {
+		if v, err := s.Next(); err != ErrSequenceExhausted {
+			t.Fatal("Expected ErrSequenceExhausted, got", v, err)
+		}
+	}
+	s = Sequence{Wrap: true}
+	s.NextN(math.MaxUint64)
+	if v, err := s.Next(); v != 0 || err != nil {
+		t.Fatal("Unexpected result:", v, err)
+	}
+}
+
+func TestSequenceWrap(t *testing.T) {
+	s := Sequence{Start: 1, Limit: 4, Wrap: true}
+	want := []uint64{1, 2, 3, 4, 1, 2}
+	for _, w := range want {
+		if v, err := s.Next(); v != w || err != nil {
+			t.Fatal("Unexpected result:", v, err, "expected", w)
+		}
+	}
+
+	// ranges do not span rounds
+	if first, err := s.NextN(3); first != 1 || err != nil {
+		t.Fatal("Unexpected result:", first, err)
+	}
+	if _, err := s.NextN(5); err != ErrSequenceExhausted {
+		t.Fatal("Expected ErrSequenceExhausted, got", err)
+	}
+}
+
+func TestSequenceBlocks(t *testing.T) {
+	s := Sequence{Start: 1, Step: 2, Limit: 100000, BlockSize: 16}
+
+	var (
+		mu   sync.Mutex
+		seen = make(map[uint64]bool)
+		wg   sync.WaitGroup
+	)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			values := make([]uint64, 0, 1000)
+			for j := 0; j < 1000; j++ {
+				v, err := s.Next()
+				if err != nil {
+					t.Error("Unexpected error:", err)
+					return
+				}
+				values = append(values, v)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			for _, v := range values {
+				if v%2 != 1 || v >= 200000 {
+					t.Error("Unexpected value", v)
+				}
+				if seen[v] {
+					t.Error("Duplicate value", v)
+				}
+				seen[v] = true
+			}
+		}()
+	}
+	wg.Wait()
+
+	// the remaining values are handed out even if they do not fill a block
+	s = Sequence{Limit: 3, BlockSize: 16}
+	for i := uint64(0); i < 3; i++ {
+		if v, err := s.Next(); v != i || err != nil {
+			t.Fatal("Unexpected result:", v, err)
+		}
+	}
+	if _, err := s.Next(); err != ErrSequenceExhausted {
+		t.Fatal("Expected ErrSequenceExhausted, got", err)
+	}
+}
+
+func BenchmarkSequence(b *testing.B) {
+	b.Run("Shared", func(b *testing.B) {
+		var s Sequence
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				s.Next()
+			}
+		})
+	})
+	b.Run("Blocks", func(b *testing.B) {
+		s := Sequence{BlockSize: 64}
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				s.Next()
+			}
+		})
+	})
+}
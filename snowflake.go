@@ -0,0 +1,173 @@
+package atom
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrClockRegression is returned by Snowflake.Next if the clock moved
+// backwards and waiting for it to catch up is disabled.
+var ErrClockRegression = errors.New("clock moved backwards")
+
+// Snowflake is a lock-free generator of unique 64-bit IDs in the style of
+// Twitter's Snowflake IDs, which are composed of a timestamp, a node ID and a
+// sequence number, from the most to the least significant bits. IDs of a
+// single generator are strictly increasing, IDs of generators with different
+// node IDs are unique and roughly ordered by time.
+//
+// The timestamp of the last ID and the sequence number are packed into a
+// single Uint64, which Next updates with a CompareAndSwap.
+//
+// The zero value uses 41 timestamp bits of milliseconds since the Unix epoch,
+// 10 node bits with node ID 0 and 12 sequence bits.
+// All fields must be set before the first use.
+type Snowflake struct {
+	_ noCopy
+
+	// Epoch is the time of timestamp 0. If zero, the Unix epoch is used.
+	Epoch time.Time
+
+	// TimeUnit is the resolution of the timestamp. If zero, one millisecond
+	// is used.
+	TimeUnit time.Duration
+
+	// TimeBits, NodeBits and SequenceBits are the widths of the parts of an
+	// ID. If all are zero, 41, 10 and 12 bits are used. Otherwise TimeBits
+	// must be positive and the sum of all widths must not exceed 64 bits.
+	TimeBits     uint
+	NodeBits     uint
+	SequenceBits uint
+
+	// Node is the node ID, which must fit into NodeBits.
+	Node uint64
+
+	// WaitOnRegression makes Next wait until the clock caught up if it moved
+	// backwards, instead of returning ErrClockRegression.
+	WaitOnRegression bool
+
+	// Now returns the current time. If nil, time.Now is used.
+	Now func() time.Time
+
+	// Sleep pauses for the given duration while Next waits for the clock.
+	// If nil, time.Sleep is used. It must be set together with Now if the
+	// injected clock does not advance on its own.
+	Sleep func(d time.Duration)
+
+	state Uint64 // timestamp of the last ID << SequenceBits | sequence number
+}
+
+// Decompose returns the parts of the given ID.
+func (s *Snowflake) Decompose(id uint64) (t time.Time, node, seq uint64) {
+	timeBits, nodeBits, seqBits := s.layout()
+	ts := id >> (nodeBits + seqBits) & mask64(timeBits)
+	node = id >> seqBits & mask64(nodeBits)
+	seq = id & mask64(seqBits)
+	return s.epoch().Add(time.Duration(ts) * s.unit()), node, seq
+}
+
+// Next returns a new unique ID.
+//
+// If all sequence numbers of the current time unit are used up, Next waits
+// for the next time unit. If the clock moved backwards, Next either waits until
+// the clock caught up or returns ErrClockRegression, see WaitOnRegression.
+// It returns an error if the time is before the epoch or does not fit into
+// TimeBits anymore.
+// It panics if the layout is invalid or the node ID does not fit into it.
+func (s *Snowflake) Next() (id uint64, err error) {
+	timeBits, nodeBits, seqBits := s.layout()
+	if s.Node > mask64(nodeBits) {
+		panic("atom: Snowflake node ID does not fit into NodeBits")
+	}
+	seqMask := mask64(seqBits)
+
+	for {
+		// The state must be loaded before the clock is read, otherwise an ID
+		// generated concurrently in between would look like a regression.
+		old := s.state.Value()
+		last, seq := old>>seqBits, old&seqMask
+
+		now := s.now()
+		d := now.Sub(s.epoch())
+		if d < 0 {
+			return 0, fmt.Errorf("atom: time %v before Snowflake epoch", now)
+		}
+		ts := uint64(d / s.unit())
+		if ts > mask64(timeBits) {
+			return 0, fmt.Errorf("atom: Snowflake timestamp exceeds %d bits", timeBits)
+		}
+
+		switch {
+		case ts < last:
+			if !s.WaitOnRegression {
+				return 0, ErrClockRegression
+			}
+			s.sleepUntil(now, last)
+			continue
+		case ts == last:
+			if seq == seqMask {
+				// sequence numbers are used up, wait for the next time unit
+				s.sleepUntil(now, last+1)
+				continue
+			}
+			seq++
+		default:
+			seq = 0
+		}
+
+		if s.state.CompareAndSwap(old, ts<<seqBits|seq) {
+			return ts<<(nodeBits+seqBits) | s.Node<<seqBits | seq, nil
+		}
+	}
+}
+
+func (s *Snowflake) epoch() time.Time {
+	if s.Epoch.IsZero() {
+		return time.Unix(0, 0)
+	}
+	return s.Epoch
+}
+
+// layout returns the widths of the parts of an ID.
+func (s *Snowflake) layout() (timeBits, nodeBits, seqBits uint) {
+	if s.TimeBits == 0 && s.NodeBits == 0 && s.SequenceBits == 0 {
+		return 41, 10, 12
+	}
+	if s.TimeBits == 0 || s.TimeBits+s.NodeBits+s.SequenceBits > 64 {
+		panic("atom: invalid Snowflake layout")
+	}
+	return s.TimeBits, s.NodeBits, s.SequenceBits
+}
+
+func (s *Snowflake) now() time.Time {
+	if s.Now != nil {
+		return s.Now()
+	}
+	return time.Now()
+}
+
+// sleepUntil sleeps until the start of the time unit ts, given the current
+// time now.
+func (s *Snowflake) sleepUntil(now time.Time, ts uint64) {
+	d := s.epoch().Add(time.Duration(ts) * s.unit()).Sub(now)
+	if d <= 0 {
+		d = time.Microsecond
+	}
+	if s.Sleep != nil {
+		s.Sleep(d)
+		return
+	}
+	time.Sleep(d)
+}
+
+func (s *Snowflake) unit() time.Duration {
+	if s.TimeUnit == 0 {
+		return time.Millisecond
+	}
+	return s.TimeUnit
+}
+
+// mask64 returns a mask of the lowest n bits.
+func mask64(n uint) uint64 {
+	return 1<<n - 1
+}
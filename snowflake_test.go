@@ -0,0 +1,192 @@
+package atom
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSnowflake(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	s := Snowflake{
+		Epoch:        time.Unix(900, 0),
+		TimeUnit:     time.Second,
+		TimeBits:     20,
+		NodeBits:     4,
+		SequenceBits: 2,
+		Node:         5,
+		Now:          clock.Now,
+	}
+
+	var last uint64
+	for i := uint64(0); i < 4; i++ {
+		id, err := s.Next()
+		if err != nil {
+			t.Fatal("Unexpected error:", err)
+		}
+		if id <= last && i > 0 {
+			t.Fatal("IDs are not increasing:", last, id)
+		}
+		last = id
+		ts, node, seq := s.Decompose(id)
+		if !ts.Equal(time.Unix(1000, 0)) || node != 5 || seq != i {
+			t.Fatal("Unexpected parts:", ts, node, seq)
+		}
+	}
+	if want := uint64(100<<6 | 5<<2 | 3); last != want {
+		t.Fatalf("Expected ID %x, got %x", want, last)
+	}
+
+	clock.Advance(time.Second)
+	id, err := s.Next()
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if ts, _, seq := s.Decompose(id); !ts.Equal(time.Unix(1001, 0)) || seq != 0 {
+		t.Fatal("Unexpected parts:", ts, seq)
+	}
+
+	// the timestamp must fit into its bits
+	clock.Advance(1 << 20 * time.Second)
+	if _, err := s.Next(); err == nil {
+		t.Fatal("Expected error for timestamp overflow")
+	}
+
+	// time before the epoch
+	clock.now = time.Unix(0, 0)
+	if _, err := s.Next(); err == nil {
+		t.Fatal("Expected error for time before the epoch")
+	}
+}
+
+func TestSnowflakeDefaults(t *testing.T) {
+	var s Snowflake
+	id, err := s.Next()
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	ts, node, seq := s.Decompose(id)
+	if d := time.Since(ts); d < 0 || d > time.Second || node != 0 || seq != 0 {
+		t.Fatal("Unexpected parts:", ts, node, seq)
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("Expected panic for node ID overflow")
+			}
+		}()
+		s := Snowflake{Node: 1 << 10}
+		s.Next()
+	}()
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("Expected panic for invalid layout")
+			}
+		}()
+		s := Snowflake{TimeBits: 40, NodeBits: 20, SequenceBits: 5}
+		s.Next()
+	}()
+}
+
+func TestSnowflakeRollover(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	var slept []time.Duration
+	s := Snowflake{
+		TimeBits:     40,
+		SequenceBits: 1,
+		Now:          clock.Now,
+		Sleep: func(d time.Duration) {
+			slept = append(slept, d)
+			clock.Advance(d)
+		},
+	}
+	s.Next()
+	s.Next()
+
+	// the sequence numbers are used up, Next must wait for the next time unit
+	id, err := s.Next()
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if len(slept) != 1 || slept[0] != time.Millisecond {
+		t.Fatal("Expected to sleep for 1ms, slept", slept)
+	}
+	if ts, _, seq := s.Decompose(id); !ts.Equal(time.Unix(1000, 1e6)) || seq != 0 {
+		t.Fatal("Unexpected parts:", ts, seq)
+	}
+}
+
+func TestSnowflakeRegression(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	var slept time.Duration
+	s := Snowflake{
+		Now: clock.Now,
+		Sleep: func(d time.Duration) {
+			slept += d
+			clock.Advance(d)
+		},
+	}
+	last, _ := s.Next()
+
+	clock.Advance(-time.Second)
+	if _, err := s.Next(); err != ErrClockRegression {
+		t.Fatal("Expected ErrClockRegression, got", err)
+	}
+	if slept != 0 {
+		t.Fatal("Next slept without WaitOnRegression")
+	}
+
+	// Next must wait until the clock caught up
+	s.WaitOnRegression = true
+	id, err := s.Next()
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if slept != time.Second {
+		t.Fatal("Expected to sleep for 1s, slept", slept)
+	}
+	if id <= last {
+		t.Fatal("IDs are not increasing:", last, id)
+	}
+}
+
+func TestSnowflakeConcurrent(t *testing.T) {
+	var s Snowflake
+
+	var (
+		mu   sync.Mutex
+		seen = make(map[uint64]bool)
+		wg   sync.WaitGroup
+	)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ids := make([]uint64, 0, 1000)
+			var last uint64
+			for j := 0; j < 1000; j++ {
+				id, err := s.Next()
+				if err != nil {
+					t.Error("Unexpected error:", err)
+					return
+				}
+				if id <= last {
+					t.Error("IDs are not increasing:", last, id)
+				}
+				last = id
+				ids = append(ids, id)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			for _, id := range ids {
+				if seen[id] {
+					t.Error("Duplicate ID", id)
+				}
+				seen[id] = true
+			}
+		}()
+	}
+	wg.Wait()
+}
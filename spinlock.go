@@ -0,0 +1,156 @@
+package atom
+
+import (
+	"runtime"
+	"sync"
+)
+
+// maxSpinBackoff is the maximum exponent of the busy-waiting backoff.
+// Beyond it, the goroutine yields the processor instead.
+const maxSpinBackoff = 6
+
+// spinBackoff implements an exponential backoff for spin loops.
+type spinBackoff uint32
+
+// wait busy-waits for an exponentially growing number of iterations and falls
+// back to yielding the processor once the maximum is reached.
+func (b *spinBackoff) wait() {
+	if *b >= maxSpinBackoff {
+		runtime.Gosched()
+		return
+	}
+	for i := 0; i < 1<<*b; i++ {
+		spinPause()
+	}
+	*b++
+}
+
+// spinPause is a deliberately empty function. Calling it keeps the busy-wait
+// loop from being optimized away.
+//
+//go:noinline
+func spinPause() {}
+
+// SpinLock is a mutual exclusion lock which busy-waits instead of parking the
+// goroutine. It is only suitable for very short critical sections.
+// The zero value is an unlocked lock.
+type SpinLock struct {
+	_     noCopy
+	state Uint32
+}
+
+// Lock locks the lock, spinning until it is available.
+func (l *SpinLock) Lock() {
+	var b spinBackoff
+	for !l.TryLock() {
+		b.wait()
+	}
+}
+
+// TryLock tries to lock the lock without spinning and reports whether it
+// succeeded.
+func (l *SpinLock) TryLock() (locked bool) {
+	return l.state.Value() == 0 && l.state.CompareAndSwap(0, 1)
+}
+
+// Unlock unlocks the lock.
+// It panics if the lock is not locked.
+func (l *SpinLock) Unlock() {
+	if !l.state.CompareAndSwap(1, 0) {
+		panic("atom: unlock of unlocked SpinLock")
+	}
+}
+
+// rwSpinWriter is the writer bit of the RWSpinLock state. The remaining bits
+// hold the number of readers.
+const rwSpinWriter = 1 << 31
+
+// RWSpinLock is a reader/writer mutual exclusion lock which busy-waits
+// instead of parking the goroutine. It is only suitable for very short
+// critical sections.
+// A writer waiting for the lock prevents new readers from acquiring it.
+// The zero value is an unlocked lock.
+type RWSpinLock struct {
+	_     noCopy
+	state Uint32
+}
+
+// Lock locks the lock for writing, spinning until it is available.
+func (l *RWSpinLock) Lock() {
+	var b spinBackoff
+
+	// announce the writer to keep new readers out
+	for {
+		s := l.state.Value()
+		if s&rwSpinWriter == 0 && l.state.CompareAndSwap(s, s|rwSpinWriter) {
+			break
+		}
+		b.wait()
+	}
+
+	// wait for the active readers to leave
+	b = 0
+	for l.state.Value() != rwSpinWriter {
+		b.wait()
+	}
+}
+
+// RLock locks the lock for reading, spinning until it is available.
+func (l *RWSpinLock) RLock() {
+	var b spinBackoff
+	for !l.TryRLock() {
+		b.wait()
+	}
+}
+
+// RLocker returns a sync.Locker which locks and unlocks the lock for reading.
+func (l *RWSpinLock) RLocker() (locker sync.Locker) {
+	return (*rwSpinReader)(l)
+}
+
+// RUnlock undoes a single RLock call.
+// It panics if the lock is not locked for reading.
+func (l *RWSpinLock) RUnlock() {
+	for {
+		s := l.state.Value()
+		if s&^rwSpinWriter == 0 {
+			panic("atom: RUnlock of unlocked RWSpinLock")
+		}
+		if l.state.CompareAndSwap(s, s-1) {
+			return
+		}
+	}
+}
+
+// TryLock tries to lock the lock for writing without spinning and reports
+// whether it succeeded.
+func (l *RWSpinLock) TryLock() (locked bool) {
+	return l.state.Value() == 0 && l.state.CompareAndSwap(0, rwSpinWriter)
+}
+
+// TryRLock tries to lock the lock for reading without spinning and reports
+// whether it succeeded.
+func (l *RWSpinLock) TryRLock() (locked bool) {
+	for {
+		s := l.state.Value()
+		if s&rwSpinWriter != 0 {
+			return false
+		}
+		if l.state.CompareAndSwap(s, s+1) {
+			return true
+		}
+	}
+}
+
+// Unlock unlocks the lock for writing.
+// It panics if the lock is not locked for writing.
+func (l *RWSpinLock) Unlock() {
+	if !l.state.CompareAndSwap(rwSpinWriter, 0) {
+		panic("atom: unlock of unlocked RWSpinLock")
+	}
+}
+
+type rwSpinReader RWSpinLock
+
+func (r *rwSpinReader) Lock()   { (*RWSpinLock)(r).RLock() }
+func (r *rwSpinReader) Unlock() { (*RWSpinLock)(r).RUnlock() }
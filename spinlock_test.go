@@ -0,0 +1,164 @@
+package atom
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+)
+
+var (
+	_ sync.Locker = (*SpinLock)(nil)
+	_ sync.Locker = (*RWSpinLock)(nil)
+)
+
+func TestSpinLock(t *testing.T) {
+	var l SpinLock
+	if !l.TryLock() {
+		t.Fatal("TryLock failed on unlocked lock")
+	}
+	if l.TryLock() {
+		t.Fatal("TryLock succeeded on locked lock")
+	}
+	l.Unlock()
+	l.Lock()
+	l.Unlock()
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("Expected panic on unlock of unlocked lock")
+			}
+		}()
+		l.Unlock()
+	}()
+
+	// guard a non-atomic counter
+	var counter int
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				l.Lock()
+				counter++
+				l.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if counter != 8000 {
+		t.Fatal("Expected counter 8000, got", counter)
+	}
+}
+
+func TestRWSpinLock(t *testing.T) {
+	var l RWSpinLock
+	if !l.TryRLock() || !l.TryRLock() {
+		t.Fatal("TryRLock failed on read-locked lock")
+	}
+	if l.TryLock() {
+		t.Fatal("TryLock succeeded on read-locked lock")
+	}
+	l.RUnlock()
+	l.RUnlock()
+
+	if !l.TryLock() {
+		t.Fatal("TryLock failed on unlocked lock")
+	}
+	if l.TryRLock() {
+		t.Fatal("TryRLock succeeded on write-locked lock")
+	}
+	l.Unlock()
+
+	for _, unlock := range []func(){l.Unlock, l.RUnlock} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatal("Expected panic on unlock of unlocked lock")
+				}
+			}()
+			unlock()
+		}()
+	}
+
+	// a waiting writer keeps new readers out
+	l.RLock()
+	locked := make(chan struct{})
+	go func() {
+		l.Lock()
+		close(locked)
+	}()
+	for l.state.Value()&rwSpinWriter == 0 {
+	}
+	if l.TryRLock() {
+		t.Fatal("TryRLock succeeded while a writer is waiting")
+	}
+	l.RUnlock()
+	<-locked
+	l.Unlock()
+
+	var counter int
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			r := l.RLocker()
+			for j := 0; j < 1000; j++ {
+				if i%2 == 0 {
+					l.Lock()
+					counter++
+					l.Unlock()
+				} else {
+					r.Lock()
+					_ = counter
+					r.Unlock()
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	if counter != 4000 {
+		t.Fatal("Expected counter 4000, got", counter)
+	}
+}
+
+func benchmarkLocker(b *testing.B, l sync.Locker) {
+	for _, goroutines := range []int{1, 4, 64} {
+		b.Run(strconv.Itoa(goroutines), func(b *testing.B) {
+			var counter int
+			var wg sync.WaitGroup
+			n := b.N / goroutines
+			b.ResetTimer()
+			for i := 0; i < goroutines; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for j := 0; j < n; j++ {
+						l.Lock()
+						counter++
+						l.Unlock()
+					}
+				}()
+			}
+			wg.Wait()
+		})
+	}
+}
+
+func BenchmarkSpinLock(b *testing.B) {
+	benchmarkLocker(b, new(SpinLock))
+}
+
+func BenchmarkRWSpinLock(b *testing.B) {
+	benchmarkLocker(b, new(RWSpinLock))
+}
+
+func BenchmarkMutex(b *testing.B) {
+	benchmarkLocker(b, new(sync.Mutex))
+}
+
+func BenchmarkRWMutex(b *testing.B) {
+	benchmarkLocker(b, new(sync.RWMutex))
+}
@@ -0,0 +1,136 @@
+package atom
+
+// SPSCRing is a bounded wait-free single-producer single-consumer FIFO ring
+// buffer.
+//
+// Push and PushBatch must only be called by a single producer goroutine at a
+// time, Pop and PopBatch only by a single consumer goroutine at a time.
+// Neither side ever waits for the other one and no operation allocates.
+// The indices are updated without change notifications, which might take a
+// lock while goroutines wait on other words.
+//
+// Both sides keep a private cached copy of the other side's index and only
+// reload the shared index when the cached one indicates a full or empty
+// buffer. This keeps the cache line of the other side's index from bouncing
+// between the producer and the consumer on every operation.
+type SPSCRing[T any] struct {
+	_          noCopy
+	_          cacheLinePad
+	head       Uint64 // next position to read, written by the consumer
+	cachedTail uint64 // consumer's copy of tail
+	_          cacheLinePad
+	tail       Uint64 // next position to write, written by the producer
+	cachedHead uint64 // producer's copy of head
+	_          cacheLinePad
+	mask       uint64
+	buf        []T
+}
+
+// NewSPSCRing returns a new SPSCRing with the given capacity.
+// It panics if the capacity is not a power of two.
+func NewSPSCRing[T any](capacity int) *SPSCRing[T] {
+	if capacity < 1 || capacity&(capacity-1) != 0 {
+		panic("atom: SPSCRing capacity must be a power of two")
+	}
+	return &SPSCRing[T]{
+		mask: uint64(capacity - 1),
+		buf:  make([]T, capacity),
+	}
+}
+
+// Cap returns the capacity of the ring buffer.
+func (r *SPSCRing[T]) Cap() (capacity int) {
+	return len(r.buf)
+}
+
+// Len returns the number of elements in the ring buffer. It is only a
+// snapshot and may be outdated by the time it is returned.
+func (r *SPSCRing[T]) Len() (n int) {
+	head := r.head.Value()
+	tail := r.tail.Value()
+	if tail <= head {
+		return 0
+	}
+	return int(tail - head)
+}
+
+// Pop removes and returns the first element. The ok result is false if the
+// ring buffer is empty.
+func (r *SPSCRing[T]) Pop() (value T, ok bool) {
+	head := r.head.Value()
+	if head == r.cachedTail {
+		r.cachedTail = r.tail.Value()
+		if head == r.cachedTail {
+			return value, false
+		}
+	}
+
+	slot := &r.buf[head&r.mask]
+	value = *slot
+	var zero T
+	*slot = zero // allow the value to be garbage collected
+	r.head.setQuiet(head + 1)
+	return value, true
+}
+
+// PopBatch removes up to len(buf) elements, stores them in buf and returns
+// their number.
+func (r *SPSCRing[T]) PopBatch(buf []T) (n int) {
+	head := r.head.Value()
+	if uint64(len(buf)) > r.cachedTail-head {
+		r.cachedTail = r.tail.Value()
+	}
+	avail := r.cachedTail - head
+	if avail > uint64(len(buf)) {
+		avail = uint64(len(buf))
+	}
+
+	var zero T
+	for i := uint64(0); i < avail; i++ {
+		slot := &r.buf[(head+i)&r.mask]
+		buf[i] = *slot
+		*slot = zero
+	}
+	if avail > 0 {
+		r.head.setQuiet(head + avail)
+	}
+	return int(avail)
+}
+
+// Push appends the value to the end of the ring buffer and reports whether
+// it succeeded. It fails if the ring buffer is full.
+func (r *SPSCRing[T]) Push(value T) (ok bool) {
+	tail := r.tail.Value()
+	if tail-r.cachedHead == uint64(len(r.buf)) {
+		r.cachedHead = r.head.Value()
+		if tail-r.cachedHead == uint64(len(r.buf)) {
+			return false
+		}
+	}
+
+	r.buf[tail&r.mask] = value
+	r.tail.setQuiet(tail + 1)
+	return true
+}
+
+// PushBatch appends as many of the given values as fit into the ring buffer
+// and returns their number.
+func (r *SPSCRing[T]) PushBatch(values []T) (n int) {
+	tail := r.tail.Value()
+	size := uint64(len(r.buf))
+	if uint64(len(values)) > size-(tail-r.cachedHead) {
+		r.cachedHead = r.head.Value()
+	}
+	free := size - (tail - r.cachedHead)
+	if free > uint64(len(values)) {
+		free = uint64(len(values))
+	}
+
+	for i := uint64(0); i < free; i++ {
+		r.buf[(tail+i)&r.mask] = values[i]
+	}
+	if free > 0 {
+		r.tail.setQuiet(tail + free)
+	}
+	return int(free)
+}
@@ -0,0 +1,190 @@
+package atom
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestSPSCRing(t *testing.T) {
+	r := NewSPSCRing[int](4)
+	if c := r.Cap(); c != 4 {
+		t.Fatal("Expected capacity 4, got", c)
+	}
+	if _, ok := r.Pop(); ok {
+		t.Fatal("Pop succeeded on empty ring")
+	}
+
+	for round := 0; round < 3; round++ {
+		for i := 0; i < 4; i++ {
+			if !r.Push(round*4 + i) {
+				t.Fatal("Push failed on non-full ring")
+			}
+		}
+		if r.Push(-1) {
+			t.Fatal("Push succeeded on full ring")
+		}
+		if n := r.Len(); n != 4 {
+			t.Fatal("Expected length 4, got", n)
+		}
+		for i := 0; i < 4; i++ {
+			if v, ok := r.Pop(); !ok || v != round*4+i {
+				t.Fatalf("Expected %d, got %d %v", round*4+i, v, ok)
+			}
+		}
+		if n := r.Len(); n != 0 {
+			t.Fatal("Expected length 0, got", n)
+		}
+	}
+}
+
+func TestSPSCRingBatch(t *testing.T) {
+	r := NewSPSCRing[int](4)
+
+	if n := r.PushBatch([]int{1, 2, 3}); n != 3 {
+		t.Fatal("Expected 3 pushed values, got", n)
+	}
+	if n := r.PushBatch([]int{4, 5, 6}); n != 1 {
+		t.Fatal("Expected 1 pushed value, got", n)
+	}
+	if n := r.PushBatch([]int{7}); n != 0 {
+		t.Fatal("Expected 0 pushed values, got", n)
+	}
+
+	buf := make([]int, 3)
+	if n := r.PopBatch(buf); n != 3 || buf[0] != 1 || buf[2] != 3 {
+		t.Fatal("Unexpected batch:", buf[:n])
+	}
+	if n := r.PushBatch([]int{5, 6}); n != 2 {
+		t.Fatal("Expected 2 pushed values, got", n)
+	}
+	if n := r.PopBatch(buf); n != 3 || buf[0] != 4 || buf[2] != 6 {
+		t.Fatal("Unexpected batch:", buf[:n])
+	}
+	if n := r.PopBatch(buf); n != 0 {
+		t.Fatal("Unexpected batch:", buf[:n])
+	}
+}
+
+func TestSPSCRingAllocs(t *testing.T) {
+	r := NewSPSCRing[int](8)
+	buf := make([]int, 4)
+	allocs := testing.AllocsPerRun(100, func() {
+		r.Push(1)
+		r.Pop()
+		r.PushBatch(buf)
+		r.PopBatch(buf)
+	})
+	if allocs != 0 {
+		t.Fatal("Expected no allocations, got", allocs)
+	}
+}
+
+func TestNewSPSCRingPanic(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("Expected panic for capacity which is not a power of two")
+		}
+	}()
+	NewSPSCRing[int](6)
+}
+
+func TestSPSCRingConcurrent(t *testing.T) {
+	const n = 10000
+	r := NewSPSCRing[int](64)
+
+	go func() {
+		batch := make([]int, 0, 8)
+		for i := 0; i < n; {
+			if i%3 == 0 {
+				batch = batch[:0]
+				for j := i; j < n && len(batch) < cap(batch); j++ {
+					batch = append(batch, j)
+				}
+				i += r.PushBatch(batch)
+			} else if r.Push(i) {
+				i++
+			} else {
+				runtime.Gosched()
+			}
+		}
+	}()
+
+	buf := make([]int, 5)
+	for next := 0; next < n; {
+		m := r.PopBatch(buf)
+		if m == 0 {
+			if v, ok := r.Pop(); ok {
+				buf[0], m = v, 1
+			} else {
+				runtime.Gosched()
+			}
+		}
+		for _, v := range buf[:m] {
+			if v != next {
+				t.Fatalf("Expected %d, got %d", next, v)
+			}
+			next++
+		}
+	}
+}
+
+func BenchmarkSPSCRing(b *testing.B) {
+	r := NewSPSCRing[int](1024)
+	b.ReportAllocs()
+	go func() {
+		for i := 0; i < b.N; {
+			if r.Push(i) {
+				i++
+			} else {
+				runtime.Gosched()
+			}
+		}
+	}()
+	for i := 0; i < b.N; {
+		if _, ok := r.Pop(); ok {
+			i++
+		} else {
+			runtime.Gosched()
+		}
+	}
+}
+
+func BenchmarkSPSCRingBatch(b *testing.B) {
+	r := NewSPSCRing[int](1024)
+	b.ReportAllocs()
+	go func() {
+		batch := make([]int, 64)
+		for i := 0; i < b.N; {
+			m := len(batch)
+			if rest := b.N - i; rest < m {
+				m = rest
+			}
+			if n := r.PushBatch(batch[:m]); n > 0 {
+				i += n
+			} else {
+				runtime.Gosched()
+			}
+		}
+	}()
+	buf := make([]int, 64)
+	for i := 0; i < b.N; {
+		if n := r.PopBatch(buf); n > 0 {
+			i += n
+		} else {
+			runtime.Gosched()
+		}
+	}
+}
+
+func BenchmarkSPSCChannel(b *testing.B) {
+	ch := make(chan int, 1024)
+	b.ReportAllocs()
+	go func() {
+		for i := 0; i < b.N; i++ {
+			ch <- i
+		}
+	}()
+	for i := 0; i < b.N; i++ {
+		<-ch
+	}
+}
@@ -0,0 +1,75 @@
+package atom
+
+// Stack is a lock-free LIFO stack (Treiber stack).
+//
+// Every Push allocates a new node, which is never reused while any goroutine
+// might still reference it. The garbage collector thus rules out the ABA
+// problem of the CompareAndSwap on the head node.
+// The zero value is an empty stack.
+type Stack[T any] struct {
+	_    noCopy
+	head Value // holds a *stackNode[T]
+}
+
+type stackNode[T any] struct {
+	value T
+	next  *stackNode[T]
+	n     int // number of nodes including this one
+}
+
+// Len returns the number of elements on the stack.
+func (s *Stack[T]) Len() (n int) {
+	if head := s.load(); head != nil {
+		return head.n
+	}
+	return 0
+}
+
+// Peek returns the top element without removing it. The ok result is false if
+// the stack is empty.
+func (s *Stack[T]) Peek() (value T, ok bool) {
+	if head := s.load(); head != nil {
+		return head.value, true
+	}
+	return value, false
+}
+
+// Pop removes and returns the top element. The ok result is false if the
+// stack is empty.
+func (s *Stack[T]) Pop() (value T, ok bool) {
+	for {
+		old := s.head.Value()
+		head, _ := old.(*stackNode[T])
+		if head == nil {
+			return value, false
+		}
+		if s.head.CompareAndSwap(old, head.next) {
+			return head.value, true
+		}
+	}
+}
+
+// Push pushes the value on top of the stack.
+func (s *Stack[T]) Push(value T) {
+	node := &stackNode[T]{value: value}
+	for {
+		// The CompareAndSwap must compare against the untyped nil interface
+		// as long as nothing was stored yet.
+		old := s.head.Value()
+		head, _ := old.(*stackNode[T])
+		node.next = head
+		node.n = 1
+		if head != nil {
+			node.n += head.n
+		}
+		if s.head.CompareAndSwap(old, node) {
+			return
+		}
+	}
+}
+
+// load returns the current head node.
+func (s *Stack[T]) load() *stackNode[T] {
+	head, _ := s.head.Value().(*stackNode[T])
+	return head
+}
@@ -0,0 +1,121 @@
+package atom
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestStack(t *testing.T) {
+	var s Stack[int]
+	if n := s.Len(); n != 0 {
+		t.Fatal("Expected initial length 0, got", n)
+	}
+	if _, ok := s.Pop(); ok {
+		t.Fatal("Pop succeeded on empty stack")
+	}
+	if _, ok := s.Peek(); ok {
+		t.Fatal("Peek succeeded on empty stack")
+	}
+
+	for i := 1; i <= 3; i++ {
+		s.Push(i)
+	}
+	if n := s.Len(); n != 3 {
+		t.Fatal("Expected length 3, got", n)
+	}
+	if v, ok := s.Peek(); !ok || v != 3 {
+		t.Fatal("Expected top element 3, got", v, ok)
+	}
+	for i := 3; i >= 1; i-- {
+		if v, ok := s.Pop(); !ok || v != i {
+			t.Fatalf("Expected %d, got %d %v", i, v, ok)
+		}
+	}
+	if _, ok := s.Pop(); ok {
+		t.Fatal("Pop succeeded on empty stack")
+	}
+
+	// reuse after the stack ran empty
+	s.Push(4)
+	if v, ok := s.Pop(); !ok || v != 4 {
+		t.Fatal("Expected 4, got", v, ok)
+	}
+	if n := s.Len(); n != 0 {
+		t.Fatal("Expected length 0, got", n)
+	}
+}
+
+func TestStackConcurrent(t *testing.T) {
+	const (
+		producers = 8
+		perWorker = 1000
+	)
+	var s Stack[int]
+
+	// A logical clock orders the operations: pushed records the time after a
+	// push returned, the consumers record the time before a pop started.
+	var (
+		clock  Uint64
+		pushed [producers * perWorker]uint64
+		pops   [producers][]stackPop
+	)
+
+	var popped [producers * perWorker]Uint32
+	var wg sync.WaitGroup
+	for i := 0; i < producers; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < perWorker; j++ {
+				v := i*perWorker + j
+				s.Push(v)
+				pushed[v] = clock.Add(1)
+				if n := s.Len(); n < 0 || n > producers*perWorker {
+					t.Error("Invalid length", n)
+				}
+			}
+		}(i)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < perWorker; {
+				start := clock.Add(1)
+				if v, ok := s.Pop(); ok {
+					popped[v].Add(1)
+					pops[i] = append(pops[i], stackPop{v, start})
+					j++
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if n := s.Len(); n != 0 {
+		t.Fatal("Expected length 0, got", n)
+	}
+	for v := range popped {
+		if n := popped[v].Value(); n != 1 {
+			t.Fatalf("Expected %d to be popped once, popped %d times", v, n)
+		}
+	}
+
+	// LIFO: if a consumer popped a before b, both pushed by the same producer
+	// with a first, b must not have been on the stack above a already when
+	// the pop of a started.
+	for _, history := range pops {
+		for i, a := range history {
+			for _, b := range history[i+1:] {
+				if a.value/perWorker == b.value/perWorker && a.value < b.value &&
+					pushed[b.value] < a.start {
+					t.Fatalf("Popped %d before %d, which was pushed later and on the stack", a.value, b.value)
+				}
+			}
+		}
+	}
+}
+
+// stackPop records a value popped from a Stack and the logical time at which
+// the pop started.
+type stackPop struct {
+	value int
+	start uint64
+}
@@ -0,0 +1,94 @@
+package atom
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrIllegalTransition is returned (wrapped in a TransitionError) when a
+	// transition was not declared as allowed.
+	ErrIllegalTransition = errors.New("illegal state transition")
+
+	// ErrStateMismatch is returned (wrapped in a TransitionError) when the
+	// current state did not match the expected state of a transition.
+	ErrStateMismatch = errors.New("current state does not match")
+)
+
+// TransitionError describes a rejected state transition.
+type TransitionError[S ~int32] struct {
+	From    S     // expected current state
+	To      S     // requested new state
+	Current S     // actual state at the time of the transition attempt
+	Err     error // ErrIllegalTransition or ErrStateMismatch
+}
+
+func (e *TransitionError[S]) Error() string {
+	if e.Err == ErrStateMismatch {
+		return fmt.Sprintf("atom: state transition from %v to %v failed: current state is %v", e.From, e.To, e.Current)
+	}
+	return fmt.Sprintf("atom: illegal state transition from %v to %v", e.From, e.To)
+}
+
+// Unwrap returns the underlying ErrIllegalTransition or ErrStateMismatch.
+func (e *TransitionError[S]) Unwrap() error {
+	return e.Err
+}
+
+// State is an atomically accessed enum-like state with validated transitions.
+// If S implements fmt.Stringer, the state names are used in error messages.
+// States must be created with NewState.
+type State[S ~int32] struct {
+	_           noCopy
+	value       Int32
+	transitions map[S][]S
+}
+
+// NewState returns a new State with the given initial state.
+// The transitions map declares for each state the states it may transition
+// to. It must not be modified afterwards and may be shared by many States.
+func NewState[S ~int32](initial S, transitions map[S][]S) *State[S] {
+	s := &State[S]{transitions: transitions}
+	s.value.Set(int32(initial))
+	return s
+}
+
+// Allowed reports whether the transition from one state to another was
+// declared as allowed.
+func (s *State[S]) Allowed(from, to S) (allowed bool) {
+	for _, t := range s.transitions[from] {
+		if t == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Transition atomically changes the state from the given state to the new
+// state. It returns a *TransitionError if the transition is not allowed or
+// if the current state does not match from.
+func (s *State[S]) Transition(from, to S) error {
+	if !s.Allowed(from, to) {
+		return &TransitionError[S]{from, to, s.Value(), ErrIllegalTransition}
+	}
+	if !s.value.CompareAndSwap(int32(from), int32(to)) {
+		return &TransitionError[S]{from, to, s.Value(), ErrStateMismatch}
+	}
+	return nil
+}
+
+// Value returns the current state.
+func (s *State[S]) Value() (value S) {
+	return S(s.value.Value())
+}
+
+// Wait blocks until the given state is reached or the context is done.
+// It returns the context's error in the latter case.
+// Note that the state might already have changed again when Wait returns.
+func (s *State[S]) Wait(ctx context.Context, state S) error {
+	_, err := s.value.WaitUntil(ctx, func(value int32) bool {
+		return S(value) == state
+	})
+	return err
+}
@@ -0,0 +1,119 @@
+package atom
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type lifecycle int32
+
+const (
+	starting lifecycle = iota
+	running
+	draining
+	stopped
+)
+
+func (l lifecycle) String() string {
+	return [...]string{"Starting", "Running", "Draining", "Stopped"}[l]
+}
+
+var lifecycleTransitions = map[lifecycle][]lifecycle{
+	starting: {running, stopped},
+	running:  {draining, stopped},
+	draining: {stopped},
+}
+
+func TestState(t *testing.T) {
+	s := NewState(starting, lifecycleTransitions)
+	if v := s.Value(); v != starting {
+		t.Fatal("Expected initial value to be Starting, got", v)
+	}
+
+	if s.Allowed(stopped, starting) {
+		t.Fatal("Undeclared transition reported as allowed")
+	}
+	if !s.Allowed(starting, running) {
+		t.Fatal("Declared transition reported as not allowed")
+	}
+
+	err := s.Transition(starting, draining)
+	if !errors.Is(err, ErrIllegalTransition) {
+		t.Fatal("Expected ErrIllegalTransition, got", err)
+	}
+	if msg := err.Error(); msg != "atom: illegal state transition from Starting to Draining" {
+		t.Fatal("Unexpected error message:", msg)
+	}
+	if v := s.Value(); v != starting {
+		t.Fatal("Value changed")
+	}
+
+	if err := s.Transition(starting, running); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if v := s.Value(); v != running {
+		t.Fatal("Value unchanged")
+	}
+
+	err = s.Transition(starting, stopped)
+	if !errors.Is(err, ErrStateMismatch) {
+		t.Fatal("Expected ErrStateMismatch, got", err)
+	}
+	var terr *TransitionError[lifecycle]
+	if !errors.As(err, &terr) || terr.Current != running {
+		t.Fatal("Expected TransitionError with current state Running, got", err)
+	}
+	if msg := err.Error(); msg != "atom: state transition from Starting to Stopped failed: current state is Running" {
+		t.Fatal("Unexpected error message:", msg)
+	}
+	if v := s.Value(); v != running {
+		t.Fatal("Value changed")
+	}
+}
+
+func TestStateWait(t *testing.T) {
+	s := NewState(starting, lifecycleTransitions)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := s.Wait(ctx, stopped); err != context.DeadlineExceeded {
+		t.Fatal("Expected context.DeadlineExceeded, got", err)
+	}
+
+	// already reached
+	if err := s.Wait(context.Background(), starting); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+
+	done := make(chan error)
+	go func() {
+		done <- s.Wait(context.Background(), stopped)
+	}()
+
+	// intermediate states must not end the wait
+	time.Sleep(time.Millisecond)
+	if err := s.Transition(starting, running); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Transition(running, draining); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-done:
+		t.Fatal("Wait returned before the state was reached:", err)
+	case <-time.After(5 * time.Millisecond):
+	}
+
+	if err := s.Transition(draining, stopped); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done; err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+
+	if n := parkedCount(); n != 0 {
+		t.Fatal("Expected no parked goroutines, got", n)
+	}
+}
@@ -1,13 +1,19 @@
+//go:build !purego && !appengine && !js
 // +build !purego,!appengine,!js
 
 package atom
 
 import (
+	"context"
 	"sync/atomic"
 	"unsafe"
 )
 
 // Pointer is a wrapper for atomically accessed unsafe.Pointer values.
+//
+// CompareAndSwap only compares the pointer itself and is thus prone to the ABA
+// problem if the memory it points to may be reused. Use Versioned or
+// VersionedIndex instead to detect intervening changes.
 type Pointer struct {
 	_     noCopy
 	value unsafe.Pointer
@@ -16,20 +22,104 @@ type Pointer struct {
 // CompareAndSwap atomically sets the new value only if the current value
 // matches the given old value and returns whether the new value was set.
 func (p *Pointer) CompareAndSwap(old, new unsafe.Pointer) (swapped bool) {
-	return atomic.CompareAndSwapPointer(&p.value, old, new)
+	swapped = atomic.CompareAndSwapPointer(&p.value, old, new)
+	if swapped {
+		notify(p)
+	}
+	return swapped
 }
 
 // Set sets the new value regardless of the previous value.
 func (p *Pointer) Set(value unsafe.Pointer) {
 	atomic.StorePointer(&p.value, value)
+	notify(p)
 }
 
 // Swap atomically sets the new value and returns the previous value.
 func (p *Pointer) Swap(new unsafe.Pointer) (old unsafe.Pointer) {
-	return atomic.SwapPointer(&p.value, new)
+	old = atomic.SwapPointer(&p.value, new)
+	notify(p)
+	return old
 }
 
 // Value returns the current value.
 func (p *Pointer) Value() (value unsafe.Pointer) {
 	return atomic.LoadPointer(&p.value)
 }
+
+// WaitChange blocks until the value differs from old or the context is done.
+// It returns the new value, or the context's error in the latter case.
+func (p *Pointer) WaitChange(ctx context.Context, old unsafe.Pointer) (new unsafe.Pointer, err error) {
+	return p.WaitUntil(ctx, func(value unsafe.Pointer) bool {
+		return value != old
+	})
+}
+
+// WaitUntil blocks until cond returns true for the current value or the
+// context is done. It returns the value which satisfied cond, or the context's
+// error in the latter case.
+// cond is evaluated after every change and must not block.
+func (p *Pointer) WaitUntil(ctx context.Context, cond func(value unsafe.Pointer) bool) (value unsafe.Pointer, err error) {
+	err = park(ctx, p, func() bool {
+		value = p.Value()
+		return cond(value)
+	})
+	return value, err
+}
+
+// addrOf returns the address of p.
+func addrOf[T any](p *T) uintptr {
+	return uintptr(unsafe.Pointer(p))
+}
+
+// sliceIdentical reports whether the memory of the elements of a and b is
+// identical. Unlike reflect.DeepEqual, it treats func values and NaNs which
+// were copied as equal, and it does not follow pointers.
+func sliceIdentical[T any](a, b []T) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	if len(a) == 0 {
+		return true
+	}
+	size := len(a) * int(unsafe.Sizeof(a[0]))
+	return string(unsafe.Slice((*byte)(unsafe.Pointer(&a[0])), size)) ==
+		string(unsafe.Slice((*byte)(unsafe.Pointer(&b[0])), size))
+}
+
+// seqValue stores the value of a SeqLock in words which are only accessed
+// atomically, so that readers may copy it concurrently to a write.
+type seqValue[T any] struct {
+	words atomic.Pointer[[]uint64] // allocated by the first store
+}
+
+// load copies the stored value to value.
+func (s *seqValue[T]) load(value *T) {
+	words := s.words.Load()
+	if words == nil {
+		var zero T
+		*value = zero
+		return
+	}
+	b := unsafe.Slice((*byte)(unsafe.Pointer(value)), unsafe.Sizeof(*value))
+	for i := range *words {
+		w := atomic.LoadUint64(&(*words)[i])
+		copy(b[i*8:], (*[8]byte)(unsafe.Pointer(&w))[:])
+	}
+}
+
+// store copies value to the stored value. Stores must be serialized.
+func (s *seqValue[T]) store(value *T) {
+	words := s.words.Load()
+	if words == nil {
+		w := make([]uint64, (unsafe.Sizeof(*value)+7)/8)
+		words = &w
+		s.words.Store(words)
+	}
+	b := unsafe.Slice((*byte)(unsafe.Pointer(value)), unsafe.Sizeof(*value))
+	for i := range *words {
+		var w uint64
+		copy((*[8]byte)(unsafe.Pointer(&w))[:], b[i*8:])
+		atomic.StoreUint64(&(*words)[i], w)
+	}
+}
@@ -1,9 +1,12 @@
+//go:build !purego && !appengine && !js
 // +build !purego,!appengine,!js
 
 package atom
 
 import (
+	"context"
 	"testing"
+	"time"
 	"unsafe"
 )
 
@@ -42,3 +45,22 @@ func TestPointer(t *testing.T) {
 		t.Fatal("Value unchanged")
 	}
 }
+
+func TestPointerWaitChange(t *testing.T) {
+	var p Pointer
+	var t1 uint64
+
+	done := make(chan error)
+	go func() {
+		_, err := p.WaitChange(context.Background(), nil)
+		done <- err
+	}()
+	for parkedCount() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	p.Set(unsafe.Pointer(&t1))
+	if err := <-done; err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+}
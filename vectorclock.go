@@ -0,0 +1,168 @@
+package atom
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// ClockOrder is the causal order of two vector clocks.
+type ClockOrder int
+
+// Orders of vector clocks.
+const (
+	// ClockEqual means that both clocks are equal.
+	ClockEqual ClockOrder = iota
+
+	// ClockBefore means that the clock happened before the other one.
+	ClockBefore
+
+	// ClockAfter means that the clock happened after the other one.
+	ClockAfter
+
+	// ClockConcurrent means that neither clock happened before the other one.
+	ClockConcurrent
+)
+
+// String returns the name of the order.
+func (o ClockOrder) String() string {
+	switch o {
+	case ClockEqual:
+		return "equal"
+	case ClockBefore:
+		return "before"
+	case ClockAfter:
+		return "after"
+	case ClockConcurrent:
+		return "concurrent"
+	}
+	return "unknown"
+}
+
+// VectorClock is a vector clock of a fixed number of nodes, a logical clock
+// which, unlike a LamportClock, can also detect concurrent events.
+//
+// Every counter is a Uint64 and updated atomically on its own. Methods
+// considering the whole vector, such as Merge and Compare, observe each
+// counter atomically but not the vector as a whole.
+type VectorClock struct {
+	_        noCopy
+	counters []Uint64
+}
+
+// NewVectorClock returns a new VectorClock of n nodes with all counters set
+// to zero.
+func NewVectorClock(n int) *VectorClock {
+	if n < 0 {
+		panic("atom: negative VectorClock size")
+	}
+	return &VectorClock{counters: make([]Uint64, n)}
+}
+
+// Compare returns the causal order of the clock relative to the other clock.
+// It panics if the clocks have a different number of nodes.
+func (c *VectorClock) Compare(other *VectorClock) (order ClockOrder) {
+	c.checkSize(other)
+	var before, after bool
+	for i := range c.counters {
+		a, b := c.counters[i].Value(), other.counters[i].Value()
+		if a < b {
+			before = true
+		} else if a > b {
+			after = true
+		}
+	}
+	switch {
+	case before && after:
+		return ClockConcurrent
+	case before:
+		return ClockBefore
+	case after:
+		return ClockAfter
+	}
+	return ClockEqual
+}
+
+// Get returns the counter of the given node.
+func (c *VectorClock) Get(node int) (counter uint64) {
+	return c.counters[node].Value()
+}
+
+// Increment increments the counter of the given node for a local or send
+// event and returns the new counter.
+func (c *VectorClock) Increment(node int) (counter uint64) {
+	return c.counters[node].Add(1)
+}
+
+// Len returns the number of nodes.
+func (c *VectorClock) Len() (n int) {
+	return len(c.counters)
+}
+
+// MarshalBinary encodes the clock as the number of nodes followed by all
+// counters, each as an unsigned varint.
+func (c *VectorClock) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, 0, binary.MaxVarintLen64*(len(c.counters)+1))
+	data = binary.AppendUvarint(data, uint64(len(c.counters)))
+	for i := range c.counters {
+		data = binary.AppendUvarint(data, c.counters[i].Value())
+	}
+	return data, nil
+}
+
+// Merge sets every counter to the maximum of its value and the corresponding
+// counter of the other clock, e.g. for a receive event.
+// It panics if the clocks have a different number of nodes.
+func (c *VectorClock) Merge(other *VectorClock) {
+	c.checkSize(other)
+	for i := range c.counters {
+		remote := other.counters[i].Value()
+		for {
+			old := c.counters[i].Value()
+			if old >= remote || c.counters[i].CompareAndSwap(old, remote) {
+				break
+			}
+		}
+	}
+}
+
+// UnmarshalBinary decodes a clock encoded by MarshalBinary and stores its
+// counters. A clock without nodes, such as the zero value, takes on the
+// number of nodes of the encoded clock, but must then not be used
+// concurrently. Otherwise the number of nodes must match.
+func (c *VectorClock) UnmarshalBinary(data []byte) error {
+	n, k := binary.Uvarint(data)
+	if k <= 0 || n > uint64(len(data)) {
+		return fmt.Errorf("atom: invalid VectorClock encoding")
+	}
+	data = data[k:]
+	if len(c.counters) != 0 && uint64(len(c.counters)) != n {
+		return fmt.Errorf("atom: VectorClock of %d nodes cannot decode %d nodes", len(c.counters), n)
+	}
+
+	counters := make([]uint64, n)
+	for i := range counters {
+		counters[i], k = binary.Uvarint(data)
+		if k <= 0 {
+			return fmt.Errorf("atom: invalid VectorClock encoding")
+		}
+		data = data[k:]
+	}
+	if len(data) != 0 {
+		return fmt.Errorf("atom: invalid VectorClock encoding")
+	}
+
+	// the clock is only changed once the whole input was decoded
+	if len(c.counters) == 0 {
+		c.counters = make([]Uint64, n)
+	}
+	for i, v := range counters {
+		c.counters[i].Set(v)
+	}
+	return nil
+}
+
+func (c *VectorClock) checkSize(other *VectorClock) {
+	if len(c.counters) != len(other.counters) {
+		panic("atom: VectorClock sizes differ")
+	}
+}
@@ -0,0 +1,143 @@
+package atom
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestClockOrder(t *testing.T) {
+	tests := map[ClockOrder]string{
+		ClockEqual:      "equal",
+		ClockBefore:     "before",
+		ClockAfter:      "after",
+		ClockConcurrent: "concurrent",
+		ClockOrder(42):  "unknown",
+	}
+	for order, name := range tests {
+		if s := order.String(); s != name {
+			t.Errorf("Expected %q, got %q", name, s)
+		}
+	}
+}
+
+func TestVectorClock(t *testing.T) {
+	a, b := NewVectorClock(3), NewVectorClock(3)
+	if a.Len() != 3 {
+		t.Fatal("Expected 3 nodes, got", a.Len())
+	}
+	if o := a.Compare(b); o != ClockEqual {
+		t.Fatal("Expected equal clocks, got", o)
+	}
+
+	if v := a.Increment(0); v != 1 {
+		t.Fatal("Expected counter 1, got", v)
+	}
+	if o := a.Compare(b); o != ClockAfter {
+		t.Fatal("Expected after, got", o)
+	}
+	if o := b.Compare(a); o != ClockBefore {
+		t.Fatal("Expected before, got", o)
+	}
+
+	b.Increment(1)
+	b.Increment(1)
+	if o := a.Compare(b); o != ClockConcurrent {
+		t.Fatal("Expected concurrent, got", o)
+	}
+
+	b.Merge(a)
+	if b.Get(0) != 1 || b.Get(1) != 2 || b.Get(2) != 0 {
+		t.Fatal("Unexpected counters after merge:", b.Get(0), b.Get(1), b.Get(2))
+	}
+	if o := a.Compare(b); o != ClockBefore {
+		t.Fatal("Expected before, got", o)
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("Expected panic for different sizes")
+			}
+		}()
+		a.Merge(NewVectorClock(2))
+	}()
+}
+
+func TestVectorClockBinary(t *testing.T) {
+	c := NewVectorClock(3)
+	c.Increment(0)
+	for i := 0; i < 300; i++ {
+		c.Increment(2)
+	}
+	data, err := c.MarshalBinary()
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+
+	var decoded VectorClock
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if o := decoded.Compare(c); o != ClockEqual {
+		t.Fatal("Decoded clock differs:", o)
+	}
+
+	if err := NewVectorClock(2).UnmarshalBinary(data); err == nil {
+		t.Fatal("Expected error for different sizes")
+	}
+	invalid := [][]byte{
+		nil,
+		data[:len(data)-1],
+		append(data[:len(data):len(data)], 0),
+		{0xff, 0xff, 0xff, 0xff, 0x0f},
+	}
+	for _, data := range invalid {
+		if err := decoded.UnmarshalBinary(data); err == nil {
+			t.Error("Expected error for invalid encoding", data)
+		}
+	}
+	if o := decoded.Compare(c); o != ClockEqual {
+		t.Fatal("Clock changed by invalid encoding:", o)
+	}
+
+	// a zero value clock keeps its size after invalid input
+	var zero VectorClock
+	if err := zero.UnmarshalBinary([]byte{2, 1}); err == nil {
+		t.Fatal("Expected error for truncated encoding")
+	}
+	if n := zero.Len(); n != 0 {
+		t.Fatal("Expected 0 nodes after invalid encoding, got", n)
+	}
+	if err := zero.UnmarshalBinary(data); err != nil || zero.Len() != 3 {
+		t.Fatal("Unexpected result:", zero.Len(), err)
+	}
+}
+
+func TestVectorClockConcurrent(t *testing.T) {
+	a, b := NewVectorClock(4), NewVectorClock(4)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func(node int) {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				a.Increment(node)
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				b.Merge(a)
+			}
+		}()
+	}
+	wg.Wait()
+
+	b.Merge(a)
+	for i := 0; i < 4; i++ {
+		if v := b.Get(i); v != 1000 {
+			t.Fatal("Expected counter 1000, got", v)
+		}
+	}
+}
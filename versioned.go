@@ -0,0 +1,115 @@
+package atom
+
+import (
+	"sync/atomic"
+)
+
+// Versioned is an atomically accessed value, typically a pool index or a
+// pointer, tagged with a stamp which is incremented on every change.
+//
+// A CompareAndSwap on a plain Pointer or Uint32 only compares the value itself.
+// It thus cannot detect the ABA problem: if the value changes from A to B and
+// back to A in between, e.g. because a pool slot or a manually managed memory
+// block was freed and reused, the swap still succeeds. A CompareAndSwap on a
+// Versioned also compares the stamp, so that it fails after any intervening
+// change, even if the same value was stored again.
+//
+// Every change allocates a new immutable pair of value and stamp. The pair is
+// never reused while any goroutine might still reference it. Use the
+// allocation-free VersionedIndex for the indices of a pool instead.
+// The zero value holds the zero value of T with stamp 0.
+type Versioned[T comparable] struct {
+	_     noCopy
+	value atomic.Pointer[versionedPair[T]]
+}
+
+type versionedPair[T comparable] struct {
+	value T
+	stamp uint64
+}
+
+// CompareAndSwap atomically sets the new value only if both the current value
+// and stamp match the expected ones and returns whether the new value was set.
+// The stamp is incremented on success and the new stamp is returned.
+func (v *Versioned[T]) CompareAndSwap(expected T, expectedStamp uint64, new T) (stamp uint64, swapped bool) {
+	old := v.value.Load()
+	var value T
+	if old != nil {
+		value, stamp = old.value, old.stamp
+	}
+	if value != expected || stamp != expectedStamp {
+		return 0, false
+	}
+	if !v.value.CompareAndSwap(old, &versionedPair[T]{new, stamp + 1}) {
+		return 0, false
+	}
+	return stamp + 1, true
+}
+
+// Load atomically returns the current value and its stamp.
+func (v *Versioned[T]) Load() (value T, stamp uint64) {
+	if p := v.value.Load(); p != nil {
+		return p.value, p.stamp
+	}
+	return value, 0
+}
+
+// Store sets the new value regardless of the previous value and increments
+// the stamp. It returns the new stamp.
+func (v *Versioned[T]) Store(value T) (stamp uint64) {
+	for {
+		old := v.value.Load()
+		if old != nil {
+			stamp = old.stamp
+		}
+		if v.value.CompareAndSwap(old, &versionedPair[T]{value, stamp + 1}) {
+			return stamp + 1
+		}
+	}
+}
+
+// VersionedIndex is an atomically accessed 32-bit index, e.g. of a pool slot,
+// tagged with a 32-bit stamp which is incremented on every change.
+//
+// Like Versioned, it detects intervening changes in CompareAndSwap, but packs
+// the index and the stamp into a single Uint64 and thus never allocates.
+// The stamp wraps around after 1<<32 changes, thus a CompareAndSwap only fails
+// to detect intervening changes if exactly a multiple of 1<<32 happened.
+// The zero value holds index 0 with stamp 0.
+type VersionedIndex struct {
+	_     noCopy
+	value Uint64
+}
+
+func packVersionedIndex(index, stamp uint32) uint64 {
+	return uint64(stamp)<<32 | uint64(index)
+}
+
+// CompareAndSwap atomically sets the new index only if both the current index
+// and stamp match the expected ones and returns whether the new index was set.
+// The stamp is incremented on success and the new stamp is returned.
+func (v *VersionedIndex) CompareAndSwap(expected, expectedStamp, new uint32) (stamp uint32, swapped bool) {
+	stamp = expectedStamp + 1
+	if !v.value.CompareAndSwap(packVersionedIndex(expected, expectedStamp), packVersionedIndex(new, stamp)) {
+		return 0, false
+	}
+	return stamp, true
+}
+
+// Load atomically returns the current index and its stamp.
+func (v *VersionedIndex) Load() (index, stamp uint32) {
+	word := v.value.Value()
+	return uint32(word), uint32(word >> 32)
+}
+
+// Store sets the new index regardless of the previous index and increments
+// the stamp. It returns the new stamp.
+func (v *VersionedIndex) Store(index uint32) (stamp uint32) {
+	for {
+		old := v.value.Value()
+		stamp = uint32(old>>32) + 1
+		if v.value.CompareAndSwap(old, packVersionedIndex(index, stamp)) {
+			return stamp
+		}
+	}
+}
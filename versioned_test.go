@@ -0,0 +1,147 @@
+package atom
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestVersioned(t *testing.T) {
+	var v Versioned[uint32]
+	if value, stamp := v.Load(); value != 0 || stamp != 0 {
+		t.Fatal("Expected zero value and stamp, got", value, stamp)
+	}
+
+	if _, ok := v.CompareAndSwap(0, 1, 5); ok {
+		t.Fatal("CompareAndSwap reported swap when the stamp did not match")
+	}
+	if s, ok := v.CompareAndSwap(0, 0, 5); !ok || s != 1 {
+		t.Fatal("CompareAndSwap did not report a swap with stamp 1, got", s, ok)
+	}
+	if value, stamp := v.Load(); value != 5 || stamp != 1 {
+		t.Fatal("Expected (5, 1), got", value, stamp)
+	}
+
+	// ABA: the value is changed and changed back in between
+	value, stamp := v.Load()
+	v.CompareAndSwap(5, 1, 7)
+	if s := v.Store(5); s != 3 {
+		t.Fatal("Expected stamp 3, got", s)
+	}
+	if _, ok := v.CompareAndSwap(value, stamp, 9); ok {
+		t.Fatal("CompareAndSwap did not detect the intervening change")
+	}
+	if s, ok := v.CompareAndSwap(5, 3, 9); !ok || s != 4 {
+		t.Fatal("CompareAndSwap did not report a swap with stamp 4, got", s, ok)
+	}
+	if value, stamp := v.Load(); value != 9 || stamp != 4 {
+		t.Fatal("Expected (9, 4), got", value, stamp)
+	}
+}
+
+func TestVersionedPointer(t *testing.T) {
+	type node struct{ next *node }
+	a, b := &node{}, &node{}
+
+	var v Versioned[*node]
+	v.Store(a)
+	head, stamp := v.Load()
+	v.Store(b)
+	v.Store(a)
+	if _, ok := v.CompareAndSwap(head, stamp, head.next); ok {
+		t.Fatal("CompareAndSwap did not detect the intervening change")
+	}
+}
+
+func TestVersionedConcurrent(t *testing.T) {
+	var v Versioned[int]
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				for {
+					value, stamp := v.Load()
+					if _, ok := v.CompareAndSwap(value, stamp, value+1); ok {
+						break
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if value, stamp := v.Load(); value != 8000 || stamp != 8000 {
+		t.Fatal("Lost updates:", value, stamp)
+	}
+}
+
+func TestVersionedIndex(t *testing.T) {
+	var v VersionedIndex
+	if index, stamp := v.Load(); index != 0 || stamp != 0 {
+		t.Fatal("Expected zero index and stamp, got", index, stamp)
+	}
+
+	if _, ok := v.CompareAndSwap(0, 1, 5); ok {
+		t.Fatal("CompareAndSwap reported swap when the stamp did not match")
+	}
+	if s, ok := v.CompareAndSwap(0, 0, 5); !ok || s != 1 {
+		t.Fatal("CompareAndSwap did not report a swap with stamp 1, got", s, ok)
+	}
+
+	// ABA: the index is changed and changed back in between
+	index, stamp := v.Load()
+	v.CompareAndSwap(5, 1, 7)
+	if s := v.Store(5); s != 3 {
+		t.Fatal("Expected stamp 3, got", s)
+	}
+	if _, ok := v.CompareAndSwap(index, stamp, 9); ok {
+		t.Fatal("CompareAndSwap did not detect the intervening change")
+	}
+	if index, stamp := v.Load(); index != 5 || stamp != 3 {
+		t.Fatal("Expected (5, 3), got", index, stamp)
+	}
+
+	// the stamp wraps around without touching the index
+	v.value.Set(packVersionedIndex(1<<32-1, 1<<32-1))
+	if s, ok := v.CompareAndSwap(1<<32-1, 1<<32-1, 2); !ok || s != 0 {
+		t.Fatal("Expected wrapped stamp 0, got", s, ok)
+	}
+	if index, stamp := v.Load(); index != 2 || stamp != 0 {
+		t.Fatal("Expected (2, 0), got", index, stamp)
+	}
+
+	if n := testing.AllocsPerRun(100, func() {
+		index, stamp := v.Load()
+		v.CompareAndSwap(index, stamp, index+1)
+		v.Store(index)
+	}); n != 0 {
+		t.Fatal("Expected no allocations, got", n)
+	}
+}
+
+func TestVersionedIndexConcurrent(t *testing.T) {
+	var v VersionedIndex
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				for {
+					index, stamp := v.Load()
+					if _, ok := v.CompareAndSwap(index, stamp, index+1); ok {
+						break
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if index, stamp := v.Load(); index != 8000 || stamp != 8000 {
+		t.Fatal("Lost updates:", index, stamp)
+	}
+}
@@ -0,0 +1,121 @@
+package atom
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// parked is the number of goroutines currently parked on any key.
+// It allows notify to skip the parking table entirely while nobody waits.
+// The parking internals use sync/atomic directly, as the wrapper types
+// themselves call notify.
+var parked int32
+
+// parkTable holds the parked goroutines, sharded by the key's address.
+// Keys are identified by their address instead of an interface value, which
+// keeps notify free of allocations and reflection. An address can not be
+// reused while a goroutine is parked on it, as park keeps the key alive.
+var parkTable [64]parkBucket
+
+type parkBucket struct {
+	parked int32 // number of goroutines parked on keys of this bucket
+	mu     sync.Mutex
+	lists  map[uintptr]*parkList
+}
+
+// parkList is the list of goroutines parked on a single key.
+// All of them are woken up at once by closing the channel.
+type parkList struct {
+	ch chan struct{}
+	n  int
+}
+
+func bucketFor(addr uintptr) *parkBucket {
+	return &parkTable[(addr>>3)%uintptr(len(parkTable))]
+}
+
+// park blocks until cond returns true or the context is done.
+// The key is passed to notify after every change which might affect the
+// outcome of cond. Spurious wakeups are possible, cond is thus re-evaluated
+// after every wakeup.
+func park[T any](ctx context.Context, key *T, cond func() bool) error {
+	if cond() {
+		return nil
+	}
+	err := parkAddr(ctx, addrOf(key), cond)
+	runtime.KeepAlive(key)
+	return err
+}
+
+func parkAddr(ctx context.Context, key uintptr, cond func() bool) error {
+	b := bucketFor(key)
+	atomic.AddInt32(&parked, 1)
+	atomic.AddInt32(&b.parked, 1)
+	defer func() {
+		atomic.AddInt32(&b.parked, -1)
+		atomic.AddInt32(&parked, -1)
+	}()
+
+	for {
+		b.mu.Lock()
+		if b.lists == nil {
+			b.lists = make(map[uintptr]*parkList)
+		}
+		l := b.lists[key]
+		if l == nil {
+			l = &parkList{ch: make(chan struct{})}
+			b.lists[key] = l
+		}
+		l.n++
+		b.mu.Unlock()
+
+		// The registration is visible before cond is re-checked, thus a
+		// change after this check is guaranteed to close the channel.
+		if cond() {
+			b.unpark(key, l)
+			return nil
+		}
+
+		select {
+		case <-l.ch:
+		case <-ctx.Done():
+			b.unpark(key, l)
+			return ctx.Err()
+		}
+	}
+}
+
+// unpark removes a goroutine which stopped waiting from the list.
+func (b *parkBucket) unpark(key uintptr, l *parkList) {
+	b.mu.Lock()
+	l.n--
+	if l.n == 0 && b.lists[key] == l {
+		delete(b.lists, key)
+	}
+	b.mu.Unlock()
+}
+
+// notify wakes up all goroutines parked on the given key.
+// While no goroutine is parked on any key, it is a single atomic load, and
+// while none is parked on a key of the same bucket, it does not lock.
+func notify[T any](key *T) {
+	if atomic.LoadInt32(&parked) != 0 {
+		notifyAddr(addrOf(key))
+	}
+}
+
+func notifyAddr(key uintptr) {
+	b := bucketFor(key)
+	if atomic.LoadInt32(&b.parked) == 0 {
+		return
+	}
+
+	b.mu.Lock()
+	if l := b.lists[key]; l != nil {
+		close(l.ch)
+		delete(b.lists, key)
+	}
+	b.mu.Unlock()
+}
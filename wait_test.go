@@ -0,0 +1,245 @@
+package atom
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func parkedCount() int32 {
+	return atomic.LoadInt32(&parked)
+}
+
+func expectNoParked(t *testing.T) {
+	t.Helper()
+	if n := parkedCount(); n != 0 {
+		t.Fatal("Expected no parked goroutines, got", n)
+	}
+	for i := range parkTable {
+		b := &parkTable[i]
+		b.mu.Lock()
+		n := len(b.lists)
+		b.mu.Unlock()
+		if n != 0 {
+			t.Fatal("Expected empty park lists, got", n)
+		}
+	}
+}
+
+func TestWaitUntil(t *testing.T) {
+	var i Int64
+
+	calls := make(chan int64, 100)
+	done := make(chan int64)
+	go func() {
+		v, err := i.WaitUntil(context.Background(), func(value int64) bool {
+			calls <- value
+			return value >= 3
+		})
+		if err != nil {
+			t.Error("Unexpected error:", err)
+		}
+		done <- v
+	}()
+
+	// wait until the waiter is parked
+	for parkedCount() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// changes not satisfying the condition must not end the wait
+	i.Add(1)
+	i.Add(1)
+	select {
+	case v := <-done:
+		t.Fatal("WaitUntil returned early with value", v)
+	case <-time.After(5 * time.Millisecond):
+	}
+
+	i.Add(1)
+	if v := <-done; v != 3 {
+		t.Fatal("Expected value 3, got", v)
+	}
+	if len(calls) < 2 {
+		t.Fatal("Expected the condition to be re-evaluated")
+	}
+	expectNoParked(t)
+
+	// already satisfied
+	v, err := i.WaitUntil(context.Background(), func(value int64) bool { return value == 3 })
+	if err != nil || v != 3 {
+		t.Fatal("Unexpected result:", v, err)
+	}
+}
+
+func TestWaitSpuriousWakeup(t *testing.T) {
+	var b Bool
+
+	done := make(chan error)
+	go func() {
+		_, err := b.WaitChange(context.Background(), false)
+		done <- err
+	}()
+	for parkedCount() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// wake up the waiter without a change and without satisfying the
+	// condition
+	notify(&b)
+	b.Set(false)
+	select {
+	case err := <-done:
+		t.Fatal("WaitChange returned after a spurious wakeup:", err)
+	case <-time.After(5 * time.Millisecond):
+	}
+
+	b.Set(true)
+	if err := <-done; err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	expectNoParked(t)
+}
+
+func TestWaitCancel(t *testing.T) {
+	var u Uint32
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	for i := 0; i < 4; i++ {
+		go func() {
+			_, err := u.WaitChange(ctx, 0)
+			done <- err
+		}()
+	}
+	for parkedCount() < 4 {
+		time.Sleep(time.Millisecond)
+	}
+
+	cancel()
+	for i := 0; i < 4; i++ {
+		if err := <-done; err != context.Canceled {
+			t.Fatal("Expected context.Canceled, got", err)
+		}
+	}
+	expectNoParked(t)
+
+	// an already satisfied condition wins over a done context
+	if _, err := u.WaitChange(ctx, 1); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+}
+
+func TestWaitChange(t *testing.T) {
+	var (
+		b   Bool
+		d   Duration
+		e   Error
+		f32 Float32
+		f64 Float64
+		i   Int
+		i32 Int32
+		i64 Int64
+		s   String
+		u   Uint
+		u32 Uint32
+		u64 Uint64
+		up  Uintptr
+		v   Value
+	)
+	errTest := errors.New("test")
+
+	tests := []struct {
+		name   string
+		wait   func(context.Context) error
+		change func()
+	}{
+		{"Bool",
+			func(ctx context.Context) (err error) { _, err = b.WaitChange(ctx, false); return },
+			func() { b.CompareAndSwap(false, true) }},
+		{"Duration",
+			func(ctx context.Context) (err error) { _, err = d.WaitChange(ctx, 0); return },
+			func() { d.Sub(time.Second) }},
+		{"Error",
+			func(ctx context.Context) (err error) { _, err = e.WaitChange(ctx, nil); return },
+			func() { e.Set(errTest) }},
+		{"Float32",
+			func(ctx context.Context) (err error) { _, err = f32.WaitChange(ctx, 0); return },
+			func() { f32.Add(1.5) }},
+		{"Float64",
+			func(ctx context.Context) (err error) { _, err = f64.WaitChange(ctx, 0); return },
+			func() { f64.Swap(1.5) }},
+		{"Int",
+			func(ctx context.Context) (err error) { _, err = i.WaitChange(ctx, 0); return },
+			func() { i.Sub(1) }},
+		{"Int32",
+			func(ctx context.Context) (err error) { _, err = i32.WaitChange(ctx, 0); return },
+			func() { i32.Swap(1) }},
+		{"Int64",
+			func(ctx context.Context) (err error) { _, err = i64.WaitChange(ctx, 0); return },
+			func() { i64.CompareAndSwap(0, 1) }},
+		{"String",
+			func(ctx context.Context) (err error) { _, err = s.WaitChange(ctx, ""); return },
+			func() { s.Set("test") }},
+		{"Uint",
+			func(ctx context.Context) (err error) { _, err = u.WaitChange(ctx, 0); return },
+			func() { u.Add(1) }},
+		{"Uint32",
+			func(ctx context.Context) (err error) { _, err = u32.WaitChange(ctx, 0); return },
+			func() { u32.Set(1) }},
+		{"Uint64",
+			func(ctx context.Context) (err error) { _, err = u64.WaitChange(ctx, 0); return },
+			func() { u64.Sub(1) }},
+		{"Uintptr",
+			func(ctx context.Context) (err error) { _, err = up.WaitChange(ctx, 0); return },
+			func() { up.Swap(1) }},
+		{"Value",
+			func(ctx context.Context) (err error) { _, err = v.WaitChange(ctx, nil); return },
+			func() { v.Set(1) }},
+	}
+
+	for _, test := range tests {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		done := make(chan error)
+		go func(wait func(context.Context) error) {
+			done <- wait(ctx)
+		}(test.wait)
+		for parkedCount() == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		test.change()
+		if err := <-done; err != nil {
+			t.Errorf("%s: Unexpected error: %v", test.name, err)
+		}
+		cancel()
+	}
+	expectNoParked(t)
+}
+
+func BenchmarkNotify(b *testing.B) {
+	b.Run("Idle", func(b *testing.B) {
+		var u Uint64
+		for i := 0; i < b.N; i++ {
+			u.Add(1)
+		}
+	})
+
+	// a goroutine parked on an unrelated key
+	b.Run("Parked", func(b *testing.B) {
+		var other Uint32
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go other.WaitChange(ctx, 0)
+		for parkedCount() == 0 {
+			time.Sleep(time.Millisecond)
+		}
+
+		var u Uint64
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			u.Add(1)
+		}
+	})
+}
@@ -0,0 +1,164 @@
+package atom
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Watched is a wrapper for atomically accessed values of type T which
+// notifies subscribers of changes, e.g. for hot-reloadable configurations.
+//
+// Reading the value is a single atomic load and is not slowed down by
+// subscribers. Set is serialized and delivers the new value to all
+// subscribers.
+type Watched[T any] struct {
+	_     noCopy
+	value atomic.Value
+
+	mu         sync.Mutex
+	channels   map[chan T]struct{}
+	callbacks  map[*watchedCallback[T]]struct{}
+	pending    []T  // values not yet delivered to the callbacks
+	delivering bool // whether a call of Set is delivering the pending values
+}
+
+type watchedCallback[T any] struct {
+	callback func(T)
+	canceled Bool
+}
+
+// watchedBox wraps the stored values, as atomic.Value requires all values to
+// be of the same concrete type, which is not the case if T is an interface.
+type watchedBox[T any] struct {
+	value T
+}
+
+// OnChange registers a callback which is called with every new value, in the
+// order the values were set. The callbacks are called one value at a time
+// without holding any lock by one of the concurrent calls of Set, which may
+// thus return before its value was delivered to the callbacks.
+// The callback may call Set, OnChange and the returned cancel function.
+// Calling cancel removes the callback. It is not called anymore after cancel
+// returned, unless it is running concurrently.
+func (w *Watched[T]) OnChange(callback func(value T)) (cancel func()) {
+	cb := &watchedCallback[T]{callback: callback}
+
+	w.mu.Lock()
+	if w.callbacks == nil {
+		w.callbacks = make(map[*watchedCallback[T]]struct{})
+	}
+	w.callbacks[cb] = struct{}{}
+	w.mu.Unlock()
+
+	return func() {
+		cb.canceled.Set(true)
+		w.mu.Lock()
+		delete(w.callbacks, cb)
+		w.mu.Unlock()
+	}
+}
+
+// Set sets the new value regardless of the previous value and delivers it to
+// all subscribers.
+func (w *Watched[T]) Set(value T) {
+	w.mu.Lock()
+	w.value.Store(watchedBox[T]{value})
+
+	for ch := range w.channels {
+		// Coalesce: replace an undelivered previous value with the new one.
+		// Only Set sends on the channel and it is serialized, thus the send
+		// can not block after the channel was drained.
+		select {
+		case ch <- value:
+		default:
+			select {
+			case <-ch:
+			default:
+			}
+			ch <- value
+		}
+	}
+
+	if len(w.callbacks) == 0 {
+		w.mu.Unlock()
+		return
+	}
+	w.pending = append(w.pending, value)
+	if w.delivering {
+		// the delivering call picks up the value
+		w.mu.Unlock()
+		return
+	}
+	w.deliver()
+}
+
+// deliver calls the callbacks with the pending values until none are left.
+// It must be called with w.mu held and releases it.
+func (w *Watched[T]) deliver() {
+	w.delivering = true
+	locked := true
+	defer func() {
+		// a panicking callback leaves the remaining values to the next Set
+		if !locked {
+			w.mu.Lock()
+		}
+		w.delivering = false
+		w.mu.Unlock()
+	}()
+
+	var callbacks []*watchedCallback[T]
+	for len(w.pending) > 0 {
+		value := w.pending[0]
+		var zero T
+		w.pending[0] = zero
+		w.pending = w.pending[1:]
+
+		callbacks = callbacks[:0]
+		for cb := range w.callbacks {
+			callbacks = append(callbacks, cb)
+		}
+
+		w.mu.Unlock()
+		locked = false
+		for _, cb := range callbacks {
+			if !cb.canceled.Value() {
+				cb.callback(value)
+			}
+		}
+		w.mu.Lock()
+		locked = true
+	}
+}
+
+// Subscribe returns a channel on which new values are delivered.
+// Values are coalesced: if the receiver falls behind, only the latest value
+// is delivered. Calling unsubscribe stops the delivery and closes the channel.
+func (w *Watched[T]) Subscribe() (values <-chan T, unsubscribe func()) {
+	ch := make(chan T, 1)
+
+	w.mu.Lock()
+	if w.channels == nil {
+		w.channels = make(map[chan T]struct{})
+	}
+	w.channels[ch] = struct{}{}
+	w.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			w.mu.Lock()
+			delete(w.channels, ch)
+			close(ch)
+			w.mu.Unlock()
+		})
+	}
+}
+
+// Value returns the current value.
+// It returns the zero value of T if there has been no call to Set.
+func (w *Watched[T]) Value() (value T) {
+	if v := w.value.Load(); v != nil {
+		return v.(watchedBox[T]).value
+	}
+	return value
+}
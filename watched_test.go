@@ -0,0 +1,181 @@
+package atom
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestWatched(t *testing.T) {
+	var w Watched[fmt.Stringer]
+	if w.Value() != nil {
+		t.Fatal("Expected initial value to be nil")
+	}
+
+	// values of different concrete types
+	w.Set(CircuitOpen)
+	if v := w.Value(); v != CircuitOpen {
+		t.Fatal("Value unchanged")
+	}
+	w.Set(running)
+	if v := w.Value(); v != running {
+		t.Fatal("Value unchanged")
+	}
+	w.Set(nil)
+	if v := w.Value(); v != nil {
+		t.Fatal("Value unchanged")
+	}
+}
+
+func TestWatchedSubscribe(t *testing.T) {
+	var w Watched[int]
+
+	values, unsubscribe := w.Subscribe()
+	other, unsubscribeOther := w.Subscribe()
+	defer unsubscribeOther()
+
+	w.Set(1)
+	if v := <-values; v != 1 {
+		t.Fatal("Expected value 1, got", v)
+	}
+
+	// undelivered values are coalesced
+	w.Set(2)
+	w.Set(3)
+	if v := <-values; v != 3 {
+		t.Fatal("Expected value 3, got", v)
+	}
+	select {
+	case v := <-values:
+		t.Fatal("Unexpected value", v)
+	default:
+	}
+	if v := <-other; v != 3 {
+		t.Fatal("Expected value 3, got", v)
+	}
+
+	unsubscribe()
+	unsubscribe()
+	if _, ok := <-values; ok {
+		t.Fatal("Expected channel to be closed")
+	}
+	w.Set(4)
+	if v := <-other; v != 4 {
+		t.Fatal("Expected value 4, got", v)
+	}
+}
+
+func TestWatchedOnChange(t *testing.T) {
+	var w Watched[string]
+
+	var got []string
+	cancel := w.OnChange(func(value string) {
+		got = append(got, value)
+	})
+	w.Set("a")
+	w.Set("b")
+	cancel()
+	w.Set("c")
+
+	if len(got) != 2 || got[0] != "a" || got[1] != "b" {
+		t.Fatal("Expected callbacks for a and b, got", got)
+	}
+	if v := w.Value(); v != "c" {
+		t.Fatal("Value unchanged")
+	}
+}
+
+func TestWatchedOnChangeReentrant(t *testing.T) {
+	var w Watched[int]
+
+	// a callback may cancel itself and set a new value; the new value is
+	// delivered after the current one
+	var got []int
+	var cancel func()
+	cancel = w.OnChange(func(value int) {
+		got = append(got, value)
+		if value == 1 {
+			cancel()
+		}
+	})
+	var other []int
+	w.OnChange(func(value int) {
+		other = append(other, value)
+		if value < 3 {
+			w.Set(value + 1)
+		}
+	})
+	w.Set(0)
+
+	if len(got) != 2 || got[0] != 0 || got[1] != 1 {
+		t.Fatal("Expected callbacks for 0 and 1, got", got)
+	}
+	if len(other) != 4 || other[0] != 0 || other[3] != 3 {
+		t.Fatal("Expected callbacks for 0 to 3 in order, got", other)
+	}
+	if v := w.Value(); v != 3 {
+		t.Fatal("Expected value 3, got", v)
+	}
+}
+
+func TestWatchedOnChangeSlow(t *testing.T) {
+	var w Watched[int]
+
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	var got []int
+	w.OnChange(func(value int) {
+		if value == 1 {
+			close(entered)
+			<-release
+		}
+		got = append(got, value)
+	})
+
+	done := make(chan struct{})
+	go func() {
+		w.Set(1)
+		close(done)
+	}()
+	<-entered
+
+	// a slow callback does not block other writers
+	w.Set(2)
+	if v := w.Value(); v != 2 {
+		t.Fatal("Expected value 2, got", v)
+	}
+	close(release)
+	<-done
+
+	if len(got) != 2 || got[0] != 1 || got[1] != 2 {
+		t.Fatal("Expected callbacks for 1 and 2 in order, got", got)
+	}
+}
+
+func TestWatchedConcurrent(t *testing.T) {
+	var w Watched[int]
+	values, unsubscribe := w.Subscribe()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				w.Set(i*1000 + j)
+				_ = w.Value()
+			}
+		}(i)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		for range values {
+		}
+		close(done)
+	}()
+
+	wg.Wait()
+	unsubscribe()
+	<-done
+}